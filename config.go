@@ -4,13 +4,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"goc-notion-rag/embedding"
+	"goc-notion-rag/notion"
+	"goc-notion-rag/rag"
+	"goc-notion-rag/rerank"
 )
 
 // Config 애플리케이션 설정 구조체
 type Config struct {
-	NotionAPIKey string `json:"notion_api_key"`
-	GeminiAPIKey string `json:"gemini_api_key"`
-	DBPath       string `json:"db_path"`
+	NotionAPIKey string             `json:"notion_api_key"`
+	GeminiAPIKey string             `json:"gemini_api_key"`
+	DBPath       string             `json:"db_path"`
+	Embedding    embedding.Config   `json:"embedding"`
+	Chunking     notion.ChunkConfig `json:"chunking"`
+	Search       rag.Config         `json:"search"`
 }
 
 // LoadConfig config.json 파일에서 설정을 로드합니다
@@ -52,14 +60,47 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("config.json에 notion_api_key가 설정되지 않았습니다")
 	}
 
-	if config.GeminiAPIKey == "" {
-		return nil, fmt.Errorf("config.json에 gemini_api_key가 설정되지 않았습니다")
-	}
-
 	// DB 경로 기본값 설정
 	if config.DBPath == "" {
 		config.DBPath = "./my-knowledge.db"
 	}
 
+	// 임베딩 제공자 기본값 설정 (Gemini는 gemini_api_key를 그대로 사용)
+	if config.Embedding.Provider == "" {
+		config.Embedding.Provider = embedding.ProviderGemini
+	}
+	if config.Embedding.Provider == embedding.ProviderGemini && config.Embedding.APIKey == "" {
+		config.Embedding.APIKey = config.GeminiAPIKey
+	}
+
+	// 재순위 제공자가 Gemini면 gemini_api_key를 그대로 사용
+	if config.Search.Rerank.Provider == rerank.ProviderGemini && config.Search.Rerank.APIKey == "" {
+		config.Search.Rerank.APIKey = config.GeminiAPIKey
+	}
+
 	return &config, nil
 }
+
+// requireGeminiKey gemini_api_key가 필요한데 설정되지 않았으면 에러를 반환합니다
+// 답변은 항상 Gemini로 생성하므로 answer가 true면 필요하고, 검색만 할 때는 Gemini를 사용하는 기능
+// (별도 API Key 없는 Gemini 임베딩·재순위, HyDE)을 설정한 경우에만 필요합니다
+func (c *Config) requireGeminiKey(answer bool) error {
+	if c.GeminiAPIKey != "" {
+		return nil
+	}
+
+	var feature string
+	switch {
+	case answer:
+		feature = "답변 생성"
+	case c.Embedding.Provider == embedding.ProviderGemini && c.Embedding.APIKey == "":
+		feature = "Gemini 임베딩"
+	case c.Search.Rerank.Provider == rerank.ProviderGemini && c.Search.Rerank.APIKey == "":
+		feature = "Gemini 재순위"
+	case c.Search.HyDE && c.Search.Mode != rag.ModeKeyword:
+		feature = "HyDE"
+	default:
+		return nil
+	}
+	return fmt.Errorf("config.json에 gemini_api_key가 설정되지 않았습니다 (%s에 필요)", feature)
+}
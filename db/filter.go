@@ -0,0 +1,232 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"goc-notion-rag/models"
+)
+
+// 필터에서 메타데이터 대신 특별하게 처리하는 필드
+const (
+	FilterFieldText  = "text"  // 청크 본문
+	FilterFieldUnder = "under" // 페이지 자신 또는 상위 페이지 ID (페이지 하위 트리)
+)
+
+// filterOps 지원하는 비교 연산자 (같은 위치에서 시작하면 긴 연산자를 우선)
+var filterOps = []string{"!=", ">=", "<=", "=", "~", ">", "<"}
+
+// Condition 필터 조건 하나 (예: title~회의록, last_edit>=2025-01-01)
+type Condition struct {
+	Field string // 메타데이터 키 (title, parent_page_id, last_edit, prop:<속성 이름> 등) 또는 text, under
+	Op    string // =, !=, ~ (포함, 대소문자 무시), >=, <=, >, <
+	Value string
+}
+
+// Filter 모든 조건을 만족하는 문서만 남기는 검색 필터
+type Filter []Condition
+
+// ParseFilter 쉼표로 구분된 필터 식을 파싱합니다
+// 쉼표가 들어가는 값(쉼표로 이어진 다중 선택 속성 등)은 큰따옴표로 감쌉니다
+// 예: "title~프로젝트, last_edit>=2025-01-01, text~배포, prop:태그~\"백엔드, 인프라\""
+func ParseFilter(expr string) (Filter, error) {
+	parts, err := splitFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter Filter
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		cond, err := parseCondition(part)
+		if err != nil {
+			return nil, err
+		}
+		filter = append(filter, cond)
+	}
+	return filter, nil
+}
+
+// splitFilter 필터 식을 큰따옴표 밖의 쉼표로 나눕니다 (따옴표 안에서는 \"와 \\로 이스케이프)
+func splitFilter(expr string) ([]string, error) {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == ',':
+			parts = append(parts, expr[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("필터의 큰따옴표가 닫히지 않았습니다: %q", expr)
+	}
+	return append(parts, expr[start:]), nil
+}
+
+// parseCondition "필드 연산자 값" 형태의 조건을 파싱합니다
+func parseCondition(part string) (Condition, error) {
+	// 필드 이름 다음에 처음 나오는 연산자를 찾음
+	opIdx, op := -1, ""
+	for _, candidate := range filterOps {
+		idx := strings.Index(part, candidate)
+		if idx < 0 {
+			continue
+		}
+		if opIdx < 0 || idx < opIdx || (idx == opIdx && len(candidate) > len(op)) {
+			opIdx, op = idx, candidate
+		}
+	}
+	if opIdx <= 0 {
+		return Condition{}, fmt.Errorf("필터 조건을 해석할 수 없습니다: %q (예: title~회의록, last_edit>=2025-01-01)", part)
+	}
+
+	cond := Condition{
+		Field: strings.TrimSpace(part[:opIdx]),
+		Op:    op,
+		Value: strings.TrimSpace(part[opIdx+len(op):]),
+	}
+	if strings.HasPrefix(cond.Value, `"`) {
+		value, err := strconv.Unquote(cond.Value)
+		if err != nil {
+			return Condition{}, fmt.Errorf("필터 조건의 따옴표로 감싼 값을 해석할 수 없습니다: %q", part)
+		}
+		cond.Value = value
+	}
+	if cond.Value == "" {
+		return Condition{}, fmt.Errorf("필터 조건의 값이 비어있습니다: %q", part)
+	}
+	return cond, nil
+}
+
+// String 필터를 다시 식으로 변환합니다 (쉼표나 따옴표, 앞뒤 공백이 있는 값은 큰따옴표로 감쌈)
+func (f Filter) String() string {
+	parts := make([]string, len(f))
+	for i, cond := range f {
+		value := cond.Value
+		if strings.ContainsAny(value, `,"`) || strings.TrimSpace(value) != value {
+			value = strconv.Quote(value)
+		}
+		parts[i] = cond.Field + cond.Op + value
+	}
+	return strings.Join(parts, ", ")
+}
+
+// where chromem의 where 인자로 넘길 수 있는 정확히 일치 조건을 반환합니다
+// 나머지 조건은 검색 후 Match로 거릅니다
+func (f Filter) where() map[string]string {
+	var where map[string]string
+	for _, cond := range f {
+		if cond.Op != "=" || cond.Field == FilterFieldText || cond.Field == FilterFieldUnder {
+			continue
+		}
+		if where == nil {
+			where = make(map[string]string)
+		}
+		where[cond.Field] = cond.Value
+	}
+	return where
+}
+
+// needsPostFilter chromem에서 처리할 수 없는 조건이 있는지 확인합니다
+func (f Filter) needsPostFilter() bool {
+	for _, cond := range f {
+		if cond.Op != "=" || cond.Field == FilterFieldText || cond.Field == FilterFieldUnder {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 문서가 모든 조건을 만족하는지 확인합니다
+func (f Filter) Match(doc *models.Document) bool {
+	for _, cond := range f {
+		if !cond.match(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// match 문서가 조건을 만족하는지 확인합니다
+func (c Condition) match(doc *models.Document) bool {
+	switch c.Field {
+	case FilterFieldText:
+		return compare(doc.Content, c.Op, c.Value)
+	case FilterFieldUnder:
+		// 페이지 자신이거나 상위 페이지 목록에 포함되는지 확인 (URL에서 복사한 하이픈 없는 ID도 허용)
+		target := normalizeID(c.Value)
+		under := normalizeID(doc.ParentPageID) == target
+		for _, id := range strings.Split(doc.Meta["ancestors"], ",") {
+			under = under || (id != "" && normalizeID(id) == target)
+		}
+		if c.Op == "!=" {
+			return !under
+		}
+		return under
+	}
+
+	value, ok := doc.Meta[c.Field]
+	if !ok {
+		// 메타데이터가 없으면 != 조건만 만족
+		return c.Op == "!="
+	}
+	return compare(value, c.Op, c.Value)
+}
+
+// compare 값을 연산자로 비교합니다
+// 둘 다 숫자면 숫자로, 아니면 문자열로 비교하며 날짜(RFC3339)는 기준 값의 길이만큼만 비교합니다
+func compare(value, op, target string) bool {
+	switch op {
+	case "=":
+		return value == target
+	case "!=":
+		return value != target
+	case "~":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(target))
+	}
+
+	var cmp int
+	a, errA := strconv.ParseFloat(value, 64)
+	b, errB := strconv.ParseFloat(target, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case a < b:
+			cmp = -1
+		case a > b:
+			cmp = 1
+		}
+	} else {
+		// "2025-01-31T10:00:00Z" <= "2025-01-31"처럼 날짜만 지정한 경우를 위해 앞부분만 비교
+		if len(value) > len(target) {
+			value = value[:len(target)]
+		}
+		cmp = strings.Compare(value, target)
+	}
+
+	switch op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	}
+	return false
+}
+
+// normalizeID Notion ID에서 하이픈을 제거합니다
+func normalizeID(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
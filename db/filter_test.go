@@ -0,0 +1,54 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseFilter 쉼표로 조건을 나누되 큰따옴표 안의 쉼표는 값으로 남기는지 확인합니다
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    Filter
+		wantErr bool
+	}{
+		{
+			expr: "title~프로젝트, last_edit>=2025-01-01",
+			want: Filter{{Field: "title", Op: "~", Value: "프로젝트"}, {Field: "last_edit", Op: ">=", Value: "2025-01-01"}},
+		},
+		{
+			expr: `prop:태그="백엔드, 인프라", text~배포`,
+			want: Filter{{Field: "prop:태그", Op: "=", Value: "백엔드, 인프라"}, {Field: "text", Op: "~", Value: "배포"}},
+		},
+		{
+			expr: `title~"따옴표 \"안\", 쉼표"`,
+			want: Filter{{Field: "title", Op: "~", Value: `따옴표 "안", 쉼표`}},
+		},
+		{expr: `prop:태그="백엔드, 인프라`, wantErr: true},
+		{expr: `title=""`, wantErr: true},
+		{expr: "", want: nil},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFilter(tt.expr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFilter(%q): 에러를 기대했지만 %v", tt.expr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFilter(%q) = %#v, 기대 %#v", tt.expr, got, tt.want)
+		}
+
+		// String으로 되돌린 식을 다시 파싱해도 같은 필터
+		again, err := ParseFilter(got.String())
+		if err != nil || !reflect.DeepEqual(again, got) {
+			t.Errorf("ParseFilter(%q).String() = %q 를 다시 파싱한 결과가 다릅니다: %#v, %v", tt.expr, got.String(), again, err)
+		}
+	}
+}
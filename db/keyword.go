@@ -0,0 +1,422 @@
+package db
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"goc-notion-rag/models"
+)
+
+// keywordIndexFile 키워드 색인을 저장하는 파일 이름 (chromem DB 디렉터리 안에 저장)
+const keywordIndexFile = "keyword_index.gob"
+
+// BM25 파라미터
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// keywordDoc 색인된 청크 하나의 단어 빈도
+type keywordDoc struct {
+	PageID string         // 원본 페이지 ID (페이지 단위 삭제용)
+	Terms  map[string]int // 단어별 출현 횟수
+	Length int            // 전체 단어 수
+}
+
+// KeywordIndex 청크 본문의 BM25 역색인
+// 한글은 글자 2-gram, 영문·숫자는 소문자 단어(와 하이픈 등으로 이어진 식별자)로 색인합니다
+type KeywordIndex struct {
+	mu       sync.RWMutex
+	path     string
+	docs     map[string]keywordDoc     // 청크 ID → 단어 빈도
+	postings map[string]map[string]int // 단어 → 청크 ID → 출현 횟수
+	totalLen int                       // 모든 청크의 단어 수 합 (평균 길이 계산용)
+	dirty    bool
+}
+
+// keywordHit 키워드 검색 결과 (청크 ID와 BM25 점수)
+type keywordHit struct {
+	ID    string
+	Score float64
+}
+
+// newKeywordIndex 빈 키워드 색인을 생성합니다
+func newKeywordIndex(path string) *KeywordIndex {
+	return &KeywordIndex{
+		path:     path,
+		docs:     make(map[string]keywordDoc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// loadKeywordIndex 디스크에서 키워드 색인을 읽어옵니다 (파일이 없으면 빈 색인)
+func loadKeywordIndex(path string) (*KeywordIndex, error) {
+	idx := newKeywordIndex(path)
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("키워드 색인 읽기 실패: %w", err)
+	}
+	defer f.Close()
+
+	var docs map[string]keywordDoc
+	if err := gob.NewDecoder(f).Decode(&docs); err != nil {
+		return nil, fmt.Errorf("키워드 색인 파싱 실패: %w", err)
+	}
+	for id, doc := range docs {
+		idx.put(id, doc)
+	}
+	idx.dirty = false
+
+	return idx, nil
+}
+
+// Add 청크를 색인합니다 (같은 ID가 있으면 교체)
+func (k *KeywordIndex) Add(doc *models.Document) {
+	terms := make(map[string]int)
+	length := 0
+	for _, term := range keywordTokens(doc.Title + "\n" + doc.Content) {
+		terms[term]++
+		length++
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.remove(doc.ID)
+	k.put(doc.ID, keywordDoc{PageID: doc.ParentPageID, Terms: terms, Length: length})
+}
+
+// Remove 청크를 색인에서 제거합니다
+func (k *KeywordIndex) Remove(ids ...string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, id := range ids {
+		k.remove(id)
+	}
+}
+
+// RemovePage 페이지에 속한 모든 청크를 색인에서 제거합니다
+func (k *KeywordIndex) RemovePage(pageID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for id, doc := range k.docs {
+		if doc.PageID == pageID {
+			k.remove(id)
+		}
+	}
+}
+
+// Reset 색인을 비웁니다
+func (k *KeywordIndex) Reset() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.docs = make(map[string]keywordDoc)
+	k.postings = make(map[string]map[string]int)
+	k.totalLen = 0
+	k.dirty = true
+}
+
+// Len 색인된 청크 수를 반환합니다
+func (k *KeywordIndex) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.docs)
+}
+
+// put 청크를 색인에 추가합니다 (호출자가 mu를 잡고 있어야 합니다)
+func (k *KeywordIndex) put(id string, doc keywordDoc) {
+	k.docs[id] = doc
+	k.totalLen += doc.Length
+	for term, count := range doc.Terms {
+		posting, ok := k.postings[term]
+		if !ok {
+			posting = make(map[string]int)
+			k.postings[term] = posting
+		}
+		posting[id] = count
+	}
+	k.dirty = true
+}
+
+// remove 청크를 색인에서 제거합니다 (호출자가 mu를 잡고 있어야 합니다)
+func (k *KeywordIndex) remove(id string) {
+	doc, ok := k.docs[id]
+	if !ok {
+		return
+	}
+	for term := range doc.Terms {
+		delete(k.postings[term], id)
+		if len(k.postings[term]) == 0 {
+			delete(k.postings, term)
+		}
+	}
+	k.totalLen -= doc.Length
+	delete(k.docs, id)
+	k.dirty = true
+}
+
+// Search 질의와 BM25 점수가 높은 순서로 청크 ID를 반환합니다 (accept가 false인 청크는 제외)
+func (k *KeywordIndex) Search(query string, limit int, accept func(id string) bool) []keywordHit {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.docs) == 0 || limit <= 0 {
+		return nil
+	}
+
+	n := float64(len(k.docs))
+	avgLen := float64(k.totalLen) / n
+
+	// 같은 단어가 질의에 여러 번 나와도 한 번만 계산
+	seen := make(map[string]bool)
+	scores := make(map[string]float64)
+	for _, term := range keywordTokens(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		posting := k.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range posting {
+			docLen := float64(k.docs[id].Length)
+			freq := float64(tf)
+			scores[id] += idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+		}
+	}
+
+	hits := make([]keywordHit, 0, len(scores))
+	for id, score := range scores {
+		if accept != nil && !accept(id) {
+			continue
+		}
+		hits = append(hits, keywordHit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits
+}
+
+// Save 변경된 색인을 디스크에 저장합니다
+func (k *KeywordIndex) Save() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.dirty {
+		return nil
+	}
+
+	// 임시 파일에 쓴 뒤 교체하여 중간에 중단되어도 기존 파일이 깨지지 않도록 함
+	tmpPath := k.path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("키워드 색인 쓰기 실패: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(k.docs); err != nil {
+		f.Close()
+		return fmt.Errorf("키워드 색인 직렬화 실패: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("키워드 색인 쓰기 실패: %w", err)
+	}
+	if err := os.Rename(tmpPath, k.path); err != nil {
+		return fmt.Errorf("키워드 색인 교체 실패: %w", err)
+	}
+
+	k.dirty = false
+	return nil
+}
+
+// KeywordSearch 질의의 단어와 BM25 점수가 높은 순서로 문서를 검색합니다
+// 결과의 Score는 BM25 점수이며 Similarity는 채우지 않습니다 (opts.MinSimilarity는 사용하지 않음)
+// 색인에는 있지만 Collection에 없는 청크는 건너뛰고 색인에서도 제거하며, 건너뛴 청크 수를 skipped로 반환합니다
+func (s *Store) KeywordSearch(ctx context.Context, query string, opts SearchOptions) (results []models.SearchResult, skipped int, err error) {
+	if opts.TopK <= 0 {
+		return nil, 0, nil
+	}
+
+	// 필터가 있으면 문서를 조회해서 조건을 확인해야 하므로 조회한 문서를 재사용
+	docs := make(map[string]*models.Document)
+	var missing []string
+	accept := func(id string) bool {
+		if len(opts.Filter) == 0 {
+			return true
+		}
+		doc, err := s.GetByID(ctx, id)
+		if err != nil {
+			missing = append(missing, id)
+			return false
+		}
+		docs[id] = doc
+		return opts.Filter.Match(doc)
+	}
+
+	hits := s.keywords.Search(query, opts.TopK, accept)
+
+	results = make([]models.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		doc, ok := docs[hit.ID]
+		if !ok {
+			var err error
+			if doc, err = s.GetByID(ctx, hit.ID); err != nil {
+				missing = append(missing, hit.ID)
+				continue
+			}
+		}
+		results = append(results, models.SearchResult{
+			Document: doc,
+			Score:    float32(hit.Score),
+		})
+	}
+
+	if len(missing) > 0 {
+		s.keywords.Remove(missing...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, len(missing), err
+	}
+
+	return results, len(missing), nil
+}
+
+// ErrNoIndexedChunk 페이지 인덱스에서 저장된 청크를 찾지 못해 키워드 색인을 다시 만들 수 없음
+var ErrNoIndexedChunk = errors.New("페이지 인덱스에서 저장된 청크를 찾지 못했습니다")
+
+// KeywordIndexStale 키워드 색인이 없거나 (동기화 중단 등으로) 색인된 청크 수가 저장된 청크 수와 다른지 확인합니다
+func (s *Store) KeywordIndexStale() bool {
+	count := s.collection.Count()
+	return count > 0 && s.keywords.Len() != count
+}
+
+// RebuildKeywordIndex Collection에 저장된 모든 청크를 읽어 키워드 색인을 다시 만들고 색인한 청크 수를 반환합니다
+// chromem-go에는 문서 목록 조회가 없으므로 페이지 인덱스에 기록된 청크 하나의 벡터로 전체 청크를 유사도 검색해서 가져오며,
+// 페이지 인덱스에서 청크를 찾지 못하면 색인을 그대로 두고 ErrNoIndexedChunk를 반환합니다
+func (s *Store) RebuildKeywordIndex(ctx context.Context) (int, error) {
+	seed, ok := s.anyChunkVector(ctx)
+	if !ok {
+		return 0, ErrNoIndexedChunk
+	}
+
+	results, err := s.collection.QueryEmbedding(ctx, seed, s.collection.Count(), nil, nil)
+	if err != nil {
+		return 0, fmt.Errorf("키워드 색인 생성 실패: %w", err)
+	}
+	s.keywords.Reset()
+	for _, result := range results {
+		s.keywords.Add(toDocument(result.ID, result.Content, result.Metadata, nil))
+	}
+
+	return s.keywords.Len(), s.keywords.Save()
+}
+
+// anyChunkVector 페이지 인덱스에 기록된 청크 중 Collection에 있는 첫 청크의 벡터를 반환합니다
+func (s *Store) anyChunkVector(ctx context.Context) ([]float32, bool) {
+	s.pagesMu.RLock()
+	pages := make([]models.PageInfo, 0, len(s.pages))
+	for _, page := range s.pages {
+		pages = append(pages, page)
+	}
+	s.pagesMu.RUnlock()
+
+	for _, page := range pages {
+		for idx := 0; idx < page.ChunkCount; idx++ {
+			doc, err := s.GetByID(ctx, models.ChunkID(page.ID, idx))
+			if err != nil {
+				// 내용이 짧아 건너뛴 청크 등은 다음 청크로
+				continue
+			}
+			if len(doc.Vector) > 0 {
+				return doc.Vector, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// keywordTokens 텍스트를 색인용 단어로 나눕니다
+// 영문·숫자는 소문자 단어로, 하이픈·밑줄·점으로 이어진 식별자(예: PROJ-123)는 전체와 각 부분을 모두 사용하고,
+// 한글·한자·가나는 띄어쓰기·조사와 상관없이 찾을 수 있도록 글자 2-gram(한 글자 단어는 그대로)으로 나눕니다
+func keywordTokens(text string) []string {
+	var tokens []string
+	runes := []rune(strings.ToLower(text))
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isWordRune(r):
+			start := i
+			for i < len(runes) && (isWordRune(runes[i]) || (isJoinRune(runes[i]) && i+1 < len(runes) && isWordRune(runes[i+1]))) {
+				i++
+			}
+			word := string(runes[start:i])
+			tokens = append(tokens, word)
+			if strings.ContainsFunc(word, isJoinRune) {
+				for _, part := range strings.FieldsFunc(word, isJoinRune) {
+					tokens = append(tokens, part)
+				}
+			}
+		case isCJKRune(r):
+			start := i
+			for i < len(runes) && isCJKRune(runes[i]) {
+				i++
+			}
+			run := runes[start:i]
+			if len(run) == 1 {
+				tokens = append(tokens, string(run))
+				continue
+			}
+			for j := 0; j+1 < len(run); j++ {
+				tokens = append(tokens, string(run[j:j+2]))
+			}
+		default:
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// isWordRune 영문·숫자 등 단어를 이루는 문자인지 확인합니다
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJKRune(r)
+}
+
+// isJoinRune 식별자 안에서 단어를 잇는 문자인지 확인합니다
+func isJoinRune(r rune) bool {
+	return r == '-' || r == '_' || r == '.'
+}
+
+// isCJKRune 한글·한자·가나 문자인지 확인합니다
+func isCJKRune(r rune) bool {
+	return unicode.Is(unicode.Hangul, r) || unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r)
+}
+
+// keywordIndexPath DB 경로에 대응하는 키워드 색인 파일 경로를 반환합니다
+func keywordIndexPath(dbPath string) string {
+	return filepath.Join(dbPath, keywordIndexFile)
+}
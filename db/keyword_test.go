@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"goc-notion-rag/models"
+)
+
+// TestRebuildKeywordIndex 색인 파일이 없는 DB에서 모든 청크로 색인을 다시 만들고, 색인에만 남은 청크는 건너뛰는지 확인합니다
+func TestRebuildKeywordIndex(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := []string{"배포 일정 회의", "deploy schedule", "배포 체크리스트"}
+	docs := make([]*models.Document, len(contents))
+	for i, content := range contents {
+		docs[i] = &models.Document{ID: models.ChunkID("page", i), ParentPageID: "page", Content: content, Vector: []float32{float32(i + 1), 1}}
+	}
+	if err := store.AddDocuments(ctx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 색인 파일과 페이지 인덱스가 없으면 다시 만들 수 없음
+	if err := os.Remove(keywordIndexPath(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = NewStore(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !store.KeywordIndexStale() {
+		t.Fatal("색인 파일이 없는데 KeywordIndexStale이 false입니다")
+	}
+	if _, err := store.RebuildKeywordIndex(ctx); !errors.Is(err, ErrNoIndexedChunk) {
+		t.Fatalf("ErrNoIndexedChunk를 기대했지만 %v", err)
+	}
+
+	// 페이지 인덱스에 첫 청크만 기록되어 있어도 모든 청크를 색인
+	if err := store.PutPages([]models.PageInfo{{ID: "page", ChunkCount: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	count, err := store.RebuildKeywordIndex(ctx)
+	if err != nil || count != len(docs) {
+		t.Fatalf("청크 %d개 (%v), 기대 %d개", count, err, len(docs))
+	}
+	if store.KeywordIndexStale() {
+		t.Error("다시 만든 뒤에도 KeywordIndexStale이 true입니다")
+	}
+
+	// Collection에서만 지운 청크는 건너뛰고 색인에서도 제거
+	if err := store.collection.Delete(ctx, nil, nil, docs[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	results, skipped, err := store.KeywordSearch(ctx, "배포", SearchOptions{TopK: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Document.ID != docs[0].ID || skipped != 1 {
+		t.Errorf("결과 %d개, 건너뜀 %d개, 기대 1개, 1개", len(results), skipped)
+	}
+	if store.KeywordIndexStale() {
+		t.Error("건너뛴 청크가 색인에서 제거되지 않았습니다")
+	}
+}
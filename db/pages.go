@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"goc-notion-rag/models"
+)
+
+// pageIndexFile 페이지 동기화 상태를 저장하는 파일 이름 (chromem DB 디렉터리 안에 저장)
+const pageIndexFile = "pages.json"
+
+// loadPages 디스크에서 페이지 동기화 상태를 읽어옵니다
+func (s *Store) loadPages() error {
+	s.pages = make(map[string]models.PageInfo)
+
+	data, err := os.ReadFile(s.pagesPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 아직 동기화 기록이 없는 DB
+			return nil
+		}
+		return fmt.Errorf("페이지 인덱스 읽기 실패: %w", err)
+	}
+
+	var pages []models.PageInfo
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return fmt.Errorf("페이지 인덱스 파싱 실패: %w", err)
+	}
+
+	for _, page := range pages {
+		s.pages[page.ID] = page
+	}
+
+	return nil
+}
+
+// savePages 페이지 동기화 상태를 디스크에 저장합니다 (호출자가 pagesMu를 잡고 있어야 합니다)
+func (s *Store) savePages() error {
+	pages := make([]models.PageInfo, 0, len(s.pages))
+	for _, page := range s.pages {
+		pages = append(pages, page)
+	}
+
+	data, err := json.MarshalIndent(pages, "", "  ")
+	if err != nil {
+		return fmt.Errorf("페이지 인덱스 직렬화 실패: %w", err)
+	}
+
+	// 임시 파일에 쓴 뒤 교체하여 중간에 중단되어도 기존 파일이 깨지지 않도록 함
+	tmpPath := s.pagesPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("페이지 인덱스 쓰기 실패: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.pagesPath); err != nil {
+		return fmt.Errorf("페이지 인덱스 교체 실패: %w", err)
+	}
+
+	return nil
+}
+
+// GetPage 저장된 페이지의 동기화 상태를 반환합니다
+func (s *Store) GetPage(pageID string) (models.PageInfo, bool) {
+	s.pagesMu.RLock()
+	defer s.pagesMu.RUnlock()
+
+	page, ok := s.pages[pageID]
+	return page, ok
+}
+
+// PutPages 페이지 동기화 상태를 기록하고 디스크에 저장합니다
+func (s *Store) PutPages(pages []models.PageInfo) error {
+	if len(pages) == 0 {
+		return nil
+	}
+
+	s.pagesMu.Lock()
+	defer s.pagesMu.Unlock()
+
+	for _, page := range pages {
+		s.pages[page.ID] = page
+	}
+
+	return s.savePages()
+}
+
+// 페이지 목록 정렬 기준
+const (
+	SortByTitle    = "title"
+	SortByLastEdit = "last_edit"
+	SortByCreated  = "created"
+	SortByChunks   = "chunks"
+)
+
+// ListOptions 페이지 목록 조회 옵션
+type ListOptions struct {
+	Title  string // 제목에 포함된 문자열 (대소문자 무시, 비어있으면 전체)
+	Sort   string // 정렬 기준 (SortByTitle 등, 비어있으면 제목순)
+	Desc   bool   // 내림차순 정렬
+	Offset int    // 건너뛸 페이지 수
+	Limit  int    // 최대 페이지 수 (0 이하면 전체)
+}
+
+// ListAll 동기화된 페이지 목록을 필터링·정렬하여 반환합니다
+// 두 번째 반환값은 Offset·Limit 적용 전 조건에 맞는 전체 페이지 수입니다
+func (s *Store) ListAll(ctx context.Context, opts ListOptions) ([]models.PageInfo, int, error) {
+	less, err := pageLess(opts.Sort)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.pagesMu.RLock()
+	title := strings.ToLower(opts.Title)
+	pages := make([]models.PageInfo, 0, len(s.pages))
+	for _, page := range s.pages {
+		if title != "" && !strings.Contains(strings.ToLower(page.Title), title) {
+			continue
+		}
+		pages = append(pages, page)
+	}
+	s.pagesMu.RUnlock()
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		if opts.Desc {
+			return less(pages[j], pages[i])
+		}
+		return less(pages[i], pages[j])
+	})
+
+	total := len(pages)
+	if opts.Offset > 0 {
+		pages = pages[min(opts.Offset, len(pages)):]
+	}
+	if opts.Limit > 0 && len(pages) > opts.Limit {
+		pages = pages[:opts.Limit]
+	}
+
+	return pages, total, nil
+}
+
+// ListByTitle 제목에 titleFilter가 포함된 페이지를 제목순으로 반환합니다 (limit이 0 이하면 전체)
+func (s *Store) ListByTitle(ctx context.Context, titleFilter string, limit int) ([]models.PageInfo, error) {
+	pages, _, err := s.ListAll(ctx, ListOptions{Title: titleFilter, Limit: limit})
+	return pages, err
+}
+
+// pageLess 정렬 기준에 맞는 비교 함수를 반환합니다 (같으면 ID순)
+func pageLess(sortBy string) (func(a, b models.PageInfo) bool, error) {
+	var key func(a, b models.PageInfo) int
+	switch sortBy {
+	case "", SortByTitle:
+		key = func(a, b models.PageInfo) int { return strings.Compare(a.Title, b.Title) }
+	case SortByLastEdit:
+		key = func(a, b models.PageInfo) int { return strings.Compare(a.LastEdit, b.LastEdit) }
+	case SortByCreated:
+		key = func(a, b models.PageInfo) int { return strings.Compare(a.Created, b.Created) }
+	case SortByChunks:
+		key = func(a, b models.PageInfo) int { return a.ChunkCount - b.ChunkCount }
+	default:
+		return nil, fmt.Errorf("지원하지 않는 정렬 기준입니다: %s (title, last_edit, created, chunks)", sortBy)
+	}
+
+	return func(a, b models.PageInfo) bool {
+		if c := key(a, b); c != 0 {
+			return c < 0
+		}
+		return a.ID < b.ID
+	}, nil
+}
+
+// pageIndexPath DB 경로에 대응하는 페이지 인덱스 파일 경로를 반환합니다
+func pageIndexPath(dbPath string) string {
+	return filepath.Join(dbPath, pageIndexFile)
+}
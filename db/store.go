@@ -4,16 +4,28 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 
 	"goc-notion-rag/models"
 
 	"github.com/philippgille/chromem-go"
 )
 
+// collectionName 문서를 저장하는 chromem Collection 이름
+const collectionName = "notion_docs"
+
 // Store 벡터 DB 저장소
 type Store struct {
 	db         *chromem.DB
 	collection *chromem.Collection
+
+	// 페이지별 동기화 상태 (증분 동기화에 사용)
+	pagesMu   sync.RWMutex
+	pages     map[string]models.PageInfo
+	pagesPath string
+
+	// 청크 본문의 BM25 키워드 색인 (키워드·하이브리드 검색에 사용)
+	keywords *KeywordIndex
 }
 
 // NewStore 새로운 벡터 DB 저장소를 생성합니다
@@ -25,19 +37,43 @@ func NewStore(dbPath string) (*Store, error) {
 	}
 
 	// Collection 생성 또는 가져오기
+	collection, err := getOrCreateCollection(db)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &Store{
+		db:         db,
+		collection: collection,
+		pagesPath:  pageIndexPath(dbPath),
+	}
+
+	// 페이지 동기화 상태 로드
+	if err := store.loadPages(); err != nil {
+		return nil, err
+	}
+
+	// 키워드 색인 로드 (저장된 청크와 맞지 않으면 KeywordIndexStale로 확인해서 호출자가 다시 생성)
+	keywords, err := loadKeywordIndex(keywordIndexPath(dbPath))
+	if err != nil {
+		return nil, err
+	}
+	store.keywords = keywords
+
+	return store, nil
+}
+
+// getOrCreateCollection 문서 Collection을 생성하거나 가져옵니다
+func getOrCreateCollection(db *chromem.DB) (*chromem.Collection, error) {
 	// metadata에 cosine 거리 계산 방식 설정
 	metadata := map[string]string{
 		"hnsw:space": "cosine",
 	}
-	collection, err := db.GetOrCreateCollection("notion_docs", metadata, nil)
+	collection, err := db.GetOrCreateCollection(collectionName, metadata, nil)
 	if err != nil {
 		return nil, fmt.Errorf("Collection 생성 실패: %w", err)
 	}
-
-	return &Store{
-		db:         db,
-		collection: collection,
-	}, nil
+	return collection, nil
 }
 
 // Exists DB 파일이 존재하는지 확인합니다
@@ -54,116 +90,134 @@ func (s *Store) Count(ctx context.Context) (int, error) {
 
 // AddDocument 문서를 벡터 DB에 추가합니다
 func (s *Store) AddDocument(ctx context.Context, doc *models.Document) error {
-	if doc.Vector == nil || len(doc.Vector) == 0 {
-		return fmt.Errorf("문서에 임베딩 벡터가 없습니다: %s", doc.ID)
+	return s.AddDocuments(ctx, []*models.Document{doc})
+}
+
+// AddDocuments 여러 문서를 한 번의 collection.Add 호출로 추가합니다
+func (s *Store) AddDocuments(ctx context.Context, docs []*models.Document) error {
+	if len(docs) == 0 {
+		return nil
 	}
 
-	// 메타데이터 구성 (chromem-go는 map[string]string을 사용)
-	metadata := make(map[string]string)
-	metadata["title"] = doc.Title
-	metadata["parent_page_id"] = doc.ParentPageID
+	ids := make([]string, len(docs))
+	vectors := make([][]float32, len(docs))
+	metadatas := make([]map[string]string, len(docs))
+	contents := make([]string, len(docs))
 
-	// Meta의 모든 필드를 메타데이터에 추가
-	for k, v := range doc.Meta {
-		metadata[k] = v
-	}
+	for i, doc := range docs {
+		if len(doc.Vector) == 0 {
+			return fmt.Errorf("문서에 임베딩 벡터가 없습니다: %s", doc.ID)
+		}
 
-	// 문서 추가 (배치로 전달)
-	ids := []string{doc.ID}
-	vectors := [][]float32{doc.Vector}
-	metadatas := []map[string]string{metadata}
-	contents := []string{doc.Content}
+		// 메타데이터 구성 (chromem-go는 map[string]string을 사용)
+		metadata := make(map[string]string)
+		metadata["title"] = doc.Title
+		metadata["parent_page_id"] = doc.ParentPageID
 
-	err := s.collection.Add(ctx, ids, vectors, metadatas, contents)
-	if err != nil {
+		// Meta의 모든 필드를 메타데이터에 추가
+		for k, v := range doc.Meta {
+			metadata[k] = v
+		}
+
+		ids[i] = doc.ID
+		vectors[i] = doc.Vector
+		metadatas[i] = metadata
+		contents[i] = doc.Content
+	}
+
+	if err := s.collection.Add(ctx, ids, vectors, metadatas, contents); err != nil {
 		return fmt.Errorf("문서 추가 실패: %w", err)
 	}
 
+	for _, doc := range docs {
+		s.keywords.Add(doc)
+	}
+
 	return nil
 }
 
-// AddDocuments 여러 문서를 배치로 추가합니다
-func (s *Store) AddDocuments(ctx context.Context, docs []*models.Document) error {
-	for i, doc := range docs {
-		if err := s.AddDocument(ctx, doc); err != nil {
-			return fmt.Errorf("문서 %d 추가 실패: %w", i, err)
-		}
-	}
-	return nil
+// SearchOptions 벡터 검색 옵션
+type SearchOptions struct {
+	TopK          int     // 가져올 최대 결과 수
+	MinSimilarity float32 // 이 값보다 유사도가 낮은 결과는 제외 (0 이하면 제외하지 않음)
+	Filter        Filter  // 메타데이터·본문 필터 (비어있으면 전체 검색)
 }
 
-// Search 유사한 문서를 검색합니다 (Top K)
-func (s *Store) Search(ctx context.Context, queryVector []float32, topK int) ([]*models.Document, error) {
-	if queryVector == nil || len(queryVector) == 0 {
+// Search 유사한 문서를 유사도와 함께 검색합니다 (유사도 내림차순)
+func (s *Store) Search(ctx context.Context, queryVector []float32, opts SearchOptions) ([]models.SearchResult, error) {
+	if len(queryVector) == 0 {
 		return nil, fmt.Errorf("쿼리 벡터가 비어있습니다")
 	}
 
+	// chromem-go는 nResults가 문서 수보다 크면 에러를 반환하므로 문서 수로 제한
+	count := s.collection.Count()
+	topK := min(opts.TopK, count)
+	if topK <= 0 {
+		return nil, nil
+	}
+
+	// 정확히 일치 조건은 chromem에서 거르고, 나머지 조건은 전체 후보를 가져와서 거름
+	nResults := topK
+	if opts.Filter.needsPostFilter() {
+		nResults = count
+	}
+
 	// 검색 실행 (QueryEmbedding 사용)
-	results, err := s.collection.QueryEmbedding(ctx, queryVector, topK, nil, nil)
+	results, err := s.collection.QueryEmbedding(ctx, queryVector, nResults, opts.Filter.where(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("검색 실패: %w", err)
 	}
 
-	// 결과를 Document로 변환 (유사도 0.7 이상만 필터링)
-	documents := make([]*models.Document, 0, len(results))
-	filteredCount := 0
+	scored := make([]models.SearchResult, 0, topK)
 	for _, result := range results {
-		// 유사도 0.7 이상만 필터링
-		if result.Similarity < 0.7 {
-			filteredCount++
+		if len(scored) >= topK {
+			break
+		}
+		if opts.MinSimilarity > 0 && result.Similarity < opts.MinSimilarity {
 			continue
 		}
-
-		// 메타데이터에서 제목 추출 (먼저 제목 확인)
-		title := ""
-		if result.Metadata != nil {
-			if t, ok := result.Metadata["title"]; ok {
-				title = t
-			}
+		doc := toDocument(result.ID, result.Content, result.Metadata, result.Embedding)
+		if !opts.Filter.Match(doc) {
+			continue
 		}
+		scored = append(scored, models.SearchResult{
+			Document:   doc,
+			Similarity: result.Similarity,
+			Score:      result.Similarity,
+		})
+	}
 
-		// 디버깅: 검색된 결과 확인 (제목, 유사도 점수만 표시, 미리보기 제거)
-		fmt.Printf("[검색 결과 %d] ID: %s", len(documents)+1, result.ID)
-		if title != "" {
-			fmt.Printf(", 제목: %s", title)
-		}
-		// 유사도 점수 표시 (0~1 범위, 높을수록 유사)
-		fmt.Printf(", 유사도: %.3f", result.Similarity)
-		fmt.Printf(", Content 길이: %d자\n", len(result.Content))
+	return scored, nil
+}
 
-		doc := &models.Document{
-			ID:      result.ID,
-			Content: result.Content,
-		}
+// toDocument chromem 문서를 Document로 변환합니다 (메타데이터에서 제목과 원본 페이지 ID 추출)
+func toDocument(id, content string, metadata map[string]string, vector []float32) *models.Document {
+	doc := &models.Document{
+		ID:      id,
+		Content: content,
+		Vector:  vector,
+	}
 
-		// 메타데이터 파싱
-		if result.Metadata != nil {
-			meta := make(map[string]string)
-			for k, v := range result.Metadata {
-				meta[k] = v
-			}
-			doc.Meta = meta
-
-			// Title 추출
-			if title, ok := meta["title"]; ok {
-				doc.Title = title
-			}
-
-			// ParentPageID 추출
-			if parentID, ok := meta["parent_page_id"]; ok {
-				doc.ParentPageID = parentID
-			}
+	// 메타데이터 파싱
+	if metadata != nil {
+		meta := make(map[string]string)
+		for k, v := range metadata {
+			meta[k] = v
 		}
+		doc.Meta = meta
 
-		documents = append(documents, doc)
-	}
+		// Title 추출
+		if title, ok := meta["title"]; ok {
+			doc.Title = title
+		}
 
-	// 필터링된 결과 정보 출력
-	if filteredCount > 0 {
-		fmt.Printf("(유사도 0.7 미만으로 필터링된 결과: %d개)\n", filteredCount)
+		// ParentPageID 추출
+		if parentID, ok := meta["parent_page_id"]; ok {
+			doc.ParentPageID = parentID
+		}
 	}
 
-	return documents, nil
+	return doc
 }
 
 // min 두 정수 중 작은 값을 반환합니다
@@ -174,27 +228,6 @@ func min(a, b int) int {
 	return b
 }
 
-// ListAll 모든 문서의 메타데이터를 반환합니다 (제목, ID 등)
-func (s *Store) ListAll(ctx context.Context, limit int) ([]*models.Document, error) {
-	// chromem-go의 Get 메서드를 사용하여 모든 문서 가져오기
-	// Get은 ID 목록을 받아서 문서를 반환합니다
-	// 하지만 모든 ID를 알 수 없으므로, 다른 방법을 사용해야 합니다
-
-	// 임의의 벡터로 검색하여 모든 문서를 가져오는 것은 불가능하므로
-	// 대신 빈 벡터나 특정 조건으로 검색하는 대신
-	// Collection의 Count와 함께 사용할 수 있는 다른 방법을 찾아야 합니다
-
-	// chromem-go는 직접적인 ListAll 메서드가 없을 수 있으므로
-	// Get 메서드를 사용하려면 ID 목록이 필요합니다
-	// 하지만 ID 목록을 얻을 수 없으므로, 이 기능은 제한적입니다
-
-	// 대안: 빈 쿼리 벡터로 검색 (작동하지 않을 수 있음)
-	// 또는 Collection의 내부 메서드를 사용할 수 있는지 확인
-
-	// 일단 빈 슬라이스를 반환하고, 나중에 구현 개선
-	return []*models.Document{}, fmt.Errorf("ListAll은 아직 구현되지 않았습니다. chromem-go의 API 제한으로 인해 모든 문서를 직접 조회할 수 없습니다")
-}
-
 // GetByID ID로 특정 문서를 가져옵니다
 func (s *Store) GetByID(ctx context.Context, docID string) (*models.Document, error) {
 	// chromem-go의 GetByID 메서드 사용
@@ -203,54 +236,91 @@ func (s *Store) GetByID(ctx context.Context, docID string) (*models.Document, er
 		return nil, fmt.Errorf("문서 조회 실패: %w", err)
 	}
 
-	doc := &models.Document{
-		ID:      result.ID,
-		Content: result.Content,
+	return toDocument(result.ID, result.Content, result.Metadata, result.Embedding), nil
+}
+
+// DeleteByPage 원본 페이지 ID(parent_page_id)에 속한 모든 청크를 삭제하고 페이지 동기화 상태도 제거합니다
+// 삭제된 청크 개수를 반환합니다
+func (s *Store) DeleteByPage(ctx context.Context, pageID string) (int, error) {
+	if pageID == "" {
+		return 0, fmt.Errorf("페이지 ID가 비어있습니다")
 	}
 
-	// 메타데이터 파싱
-	if result.Metadata != nil {
-		meta := make(map[string]string)
-		for k, v := range result.Metadata {
-			meta[k] = v
+	before := s.collection.Count()
+	where := map[string]string{"parent_page_id": pageID}
+	if err := s.collection.Delete(ctx, where, nil); err != nil {
+		return 0, fmt.Errorf("페이지 %s 삭제 실패: %w", pageID, err)
+	}
+	removed := before - s.collection.Count()
+	s.keywords.RemovePage(pageID)
+
+	s.pagesMu.Lock()
+	defer s.pagesMu.Unlock()
+	if _, ok := s.pages[pageID]; ok {
+		delete(s.pages, pageID)
+		if err := s.savePages(); err != nil {
+			return removed, err
 		}
-		doc.Meta = meta
+	}
 
-		// Title 추출
-		if title, ok := meta["title"]; ok {
-			doc.Title = title
-		}
+	return removed, nil
+}
 
-		// ParentPageID 추출
-		if parentID, ok := meta["parent_page_id"]; ok {
-			doc.ParentPageID = parentID
-		}
+// DeleteDocuments 지정한 ID의 문서(청크)들을 삭제합니다
+// 존재하지 않는 ID는 무시하며, 실제로 삭제된 개수를 반환합니다
+func (s *Store) DeleteDocuments(ctx context.Context, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
 	}
 
-	return doc, nil
+	before := s.collection.Count()
+	if err := s.collection.Delete(ctx, nil, nil, ids...); err != nil {
+		return 0, fmt.Errorf("문서 삭제 실패: %w", err)
+	}
+	s.keywords.Remove(ids...)
+
+	return before - s.collection.Count(), nil
 }
 
-// ListByTitle 제목으로 문서를 검색합니다 (메타데이터 필터링)
-func (s *Store) ListByTitle(ctx context.Context, titleFilter string, limit int) ([]*models.Document, error) {
-	// chromem-go는 메타데이터 필터링을 지원하지 않을 수 있으므로
-	// 모든 문서를 가져와서 필터링해야 합니다
-	// 하지만 ListAll이 구현되지 않았으므로, 이 기능도 제한적입니다
+// PageIDs 동기화 상태가 기록된 모든 페이지 ID를 반환합니다
+func (s *Store) PageIDs() []string {
+	s.pagesMu.RLock()
+	defer s.pagesMu.RUnlock()
 
-	// 대안: 제목을 포함한 검색 쿼리를 사용
-	// 제목을 임베딩하여 검색하는 방법을 사용할 수 있습니다
-	return []*models.Document{}, fmt.Errorf("ListByTitle은 아직 구현되지 않았습니다")
+	ids := make([]string, 0, len(s.pages))
+	for id := range s.pages {
+		ids = append(ids, id)
+	}
+	return ids
 }
 
 // Clear 모든 문서를 삭제합니다 (리로드 시 사용)
+// chromem-go에는 Collection 비우기가 없으므로 Collection을 삭제하고 다시 생성합니다
 func (s *Store) Clear(ctx context.Context) error {
-	// chromem-go는 직접적인 Clear 메서드가 없을 수 있으므로
-	// Collection을 삭제하고 다시 생성하는 방식 사용
-	// 이는 구현에 따라 다를 수 있음
-	return nil
+	if err := s.db.DeleteCollection(collectionName); err != nil {
+		return fmt.Errorf("Collection 삭제 실패: %w", err)
+	}
+
+	collection, err := getOrCreateCollection(s.db)
+	if err != nil {
+		return err
+	}
+	s.collection = collection
+	s.keywords.Reset()
+
+	s.pagesMu.Lock()
+	defer s.pagesMu.Unlock()
+	s.pages = make(map[string]models.PageInfo)
+	return s.savePages()
 }
 
-// Close DB 연결을 닫습니다
+// Flush 메모리에만 반영된 키워드 색인을 디스크에 저장합니다
+// chromem-go의 PersistentDB는 추가·삭제 시 바로 저장되지만 키워드 색인은 동기화가 끝날 때 한 번에 저장합니다
+func (s *Store) Flush() error {
+	return s.keywords.Save()
+}
+
+// Close DB 연결을 닫습니다 (저장되지 않은 키워드 색인 저장)
 func (s *Store) Close() error {
-	// chromem-go의 PersistentDB는 자동으로 저장되므로 별도의 Close가 필요 없을 수 있음
-	return nil
+	return s.Flush()
 }
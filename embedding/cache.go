@@ -0,0 +1,216 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheFile 임베딩 캐시를 저장하는 파일 이름 (chromem DB 디렉터리 안에 저장)
+const cacheFile = "embedding_cache.gob"
+
+// Cache 텍스트 내용의 해시를 키로 임베딩 벡터를 보관하는 영구 캐시
+// 키는 (임베딩 모델, task type, 텍스트의 SHA-256)으로 구성되며 여러 고루틴에서 함께 사용할 수 있습니다
+type Cache struct {
+	mu      sync.RWMutex
+	path    string
+	entries map[string][]float32
+	dirty   bool
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CachePath DB 경로에 대응하는 임베딩 캐시 파일 경로를 반환합니다
+func CachePath(dbPath string) string {
+	return filepath.Join(dbPath, cacheFile)
+}
+
+// CacheKey 캐시 키를 생성합니다
+func CacheKey(modelID, taskType, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return modelID + "|" + taskType + "|" + hex.EncodeToString(sum[:])
+}
+
+// OpenCache 디스크에서 임베딩 캐시를 읽어옵니다 (파일이 없으면 빈 캐시를 반환)
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{
+		path:    path,
+		entries: make(map[string][]float32),
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 아직 캐시가 없는 DB
+			return c, nil
+		}
+		return nil, fmt.Errorf("임베딩 캐시 읽기 실패: %w", err)
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&c.entries); err != nil {
+		return nil, fmt.Errorf("임베딩 캐시 파싱 실패: %w", err)
+	}
+
+	return c, nil
+}
+
+// Get 캐시된 벡터를 반환하고 적중/미스 횟수를 집계합니다
+func (c *Cache) Get(key string) ([]float32, bool) {
+	c.mu.RLock()
+	vector, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return vector, ok
+}
+
+// Put 벡터를 캐시에 기록합니다 (디스크에는 Save 호출 시 저장)
+func (c *Cache) Put(key string, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = vector
+	c.dirty = true
+}
+
+// Len 캐시된 항목 수를 반환합니다
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Hits 캐시 적중 횟수를 반환합니다
+func (c *Cache) Hits() int64 {
+	return c.hits.Load()
+}
+
+// Misses 캐시 미스 횟수를 반환합니다
+func (c *Cache) Misses() int64 {
+	return c.misses.Load()
+}
+
+// Prune keep에 없는 항목을 모두 삭제하고 삭제된 개수를 반환합니다
+func (c *Cache) Prune(keep map[string]bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.entries {
+		if !keep[key] {
+			delete(c.entries, key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		c.dirty = true
+	}
+	return removed
+}
+
+// Save 변경된 캐시를 디스크에 저장합니다
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+
+	// 임시 파일에 쓴 뒤 교체하여 중간에 중단되어도 기존 파일이 깨지지 않도록 함
+	tmpPath := c.path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("임베딩 캐시 쓰기 실패: %w", err)
+	}
+	if err := gob.NewEncoder(f).Encode(c.entries); err != nil {
+		f.Close()
+		return fmt.Errorf("임베딩 캐시 직렬화 실패: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("임베딩 캐시 쓰기 실패: %w", err)
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		return fmt.Errorf("임베딩 캐시 교체 실패: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// cachedEmbedder API를 호출하기 전에 캐시를 먼저 확인하는 Embedder
+type cachedEmbedder struct {
+	Embedder
+	cache *Cache
+}
+
+// WithCache embedder를 감싸서 캐시에 있는 텍스트는 API를 호출하지 않도록 합니다
+// 반환된 Embedder를 닫으면 감싼 embedder도 닫힙니다 (캐시 저장은 호출자가 Save로 수행)
+func WithCache(embedder Embedder, cache *Cache) Embedder {
+	if cache == nil {
+		return embedder
+	}
+	return &cachedEmbedder{Embedder: embedder, cache: cache}
+}
+
+// EmbedText 캐시를 확인한 뒤 없으면 텍스트를 임베딩합니다
+func (e *cachedEmbedder) EmbedText(ctx context.Context, text string, taskType string) ([]float32, error) {
+	vectors, err := e.EmbedTexts(ctx, []string{text}, taskType)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedTexts 캐시에 없는 텍스트만 모아서 임베딩하고 결과를 캐시에 기록합니다
+func (e *cachedEmbedder) EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	modelID := e.ModelID()
+	results := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+
+	var (
+		missTexts []string
+		missIdx   []int
+	)
+	for i, text := range texts {
+		keys[i] = CacheKey(modelID, taskType, text)
+		if vector, ok := e.cache.Get(keys[i]); ok {
+			results[i] = vector
+			continue
+		}
+		missTexts = append(missTexts, text)
+		missIdx = append(missIdx, i)
+	}
+
+	if len(missTexts) == 0 {
+		return results, nil
+	}
+
+	vectors, err := e.Embedder.EmbedTexts(ctx, missTexts, taskType)
+	if err != nil {
+		return nil, err
+	}
+	for j, vector := range vectors {
+		i := missIdx[j]
+		results[i] = vector
+		e.cache.Put(keys[i], vector)
+	}
+
+	return results, nil
+}
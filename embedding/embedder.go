@@ -0,0 +1,115 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// 임베딩 task type
+const (
+	TaskRetrievalDocument = "RETRIEVAL_DOCUMENT" // 저장 시
+	TaskRetrievalQuery    = "RETRIEVAL_QUERY"    // 검색 시
+)
+
+// 임베딩 제공자
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai" // OpenAI 호환 HTTP 엔드포인트
+	ProviderOllama = "ollama" // Ollama 스타일 로컬 엔드포인트
+)
+
+// Embedder 텍스트를 임베딩 벡터로 변환하는 인터페이스
+// 구현체는 여러 고루틴에서 동시에 호출해도 안전해야 합니다 (task type과 요청 ctx는 호출마다 전달)
+type Embedder interface {
+	// EmbedText 텍스트 하나를 임베딩합니다
+	// taskType: TaskRetrievalDocument (저장 시) 또는 TaskRetrievalQuery (검색 시)
+	EmbedText(ctx context.Context, text string, taskType string) ([]float32, error)
+	// EmbedTexts 여러 텍스트를 배치로 임베딩합니다 (결과는 입력과 같은 순서)
+	EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error)
+	// Dimension 임베딩 벡터 차원 수 (아직 알 수 없으면 0)
+	Dimension() int
+	// ModelID 제공자와 모델(설정한 차원 수 포함)을 나타내는 식별자 (예: "gemini/gemini-embedding-001", "ollama/bge-m3@1024")
+	ModelID() string
+	// Close 리소스를 정리합니다
+	Close() error
+}
+
+// Config 임베딩 제공자 설정 (config.json의 "embedding" 항목)
+type Config struct {
+	Provider  string `json:"provider"`   // gemini, openai, ollama
+	Model     string `json:"model"`      // 모델 이름
+	BaseURL   string `json:"base_url"`   // 엔드포인트 주소 (openai/ollama, gemini는 비어있으면 기본 API 주소)
+	APIKey    string `json:"api_key"`    // 제공자 API Key (gemini는 gemini_api_key 사용 가능)
+	Dimension int    `json:"dimension"`  // 벡터 차원 수 (0이면 첫 응답에서 확인)
+	BatchSize int    `json:"batch_size"` // 한 번의 요청으로 임베딩할 최대 텍스트 수 (0이면 DefaultBatchSize)
+	MaxTokens int    `json:"max_tokens"` // 모델의 최대 입력 토큰 수 (0이면 제공자별 기본값)
+	Tokenizer string `json:"tokenizer"`  // 토큰 수 계산 방식: estimate, runes (기본값: estimate)
+}
+
+// DefaultBatchSize 기본 배치 크기 (Gemini BatchEmbedContents의 요청당 최대 개수)
+const DefaultBatchSize = 100
+
+// modelID 제공자와 모델 이름으로 ModelID를 만듭니다 (예: "gemini/gemini-embedding-001")
+// 차원 수를 설정했으면 "@768"처럼 붙여서 차원을 바꾸면 캐시 키가 달라지도록 합니다 (설정하지 않았으면 붙이지 않음)
+func (c Config) modelID(provider, model string) string {
+	id := provider + "/" + model
+	if c.Dimension > 0 {
+		id += "@" + strconv.Itoa(c.Dimension)
+	}
+	return id
+}
+
+// batchSize 설정된 배치 크기를 반환합니다 (limit이 0보다 크면 limit을 넘지 않도록 제한)
+func (c Config) batchSize(limit int) int {
+	size := c.BatchSize
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	if limit > 0 && size > limit {
+		size = limit
+	}
+	return size
+}
+
+// EffectiveBatchSize 설정된 배치 크기를 반환합니다 (설정하지 않았으면 DefaultBatchSize)
+func (c Config) EffectiveBatchSize() int {
+	return c.batchSize(0)
+}
+
+// splitBatches n개의 텍스트를 size개씩 나눈 구간 [start, end) 목록을 반환합니다
+func splitBatches(n, size int) [][2]int {
+	var ranges [][2]int
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// New 설정에 맞는 임베딩 생성기를 생성합니다
+func New(ctx context.Context, cfg Config) (Embedder, error) {
+	var (
+		embedder Embedder
+		err      error
+	)
+
+	switch cfg.Provider {
+	case "", ProviderGemini:
+		embedder, err = NewGeminiEmbedder(ctx, cfg)
+	case ProviderOpenAI:
+		embedder, err = NewOpenAIEmbedder(ctx, cfg)
+	case ProviderOllama:
+		embedder, err = NewOllamaEmbedder(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("지원하지 않는 임베딩 제공자입니다: %s", cfg.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return embedder, nil
+}
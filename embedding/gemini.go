@@ -3,127 +3,167 @@ package embedding
 import (
 	"context"
 	"fmt"
-	"strings"
-	"time"
+	"sync/atomic"
+
+	"goc-notion-rag/internal/retry"
 
 	"github.com/google/generative-ai-go/genai"
 	"google.golang.org/api/option"
 )
 
-// Embedder Gemini API를 사용하여 텍스트를 임베딩으로 변환하는 구조체
-type Embedder struct {
-	client *genai.Client
-	model  *genai.EmbeddingModel
-	ctx    context.Context
+// defaultGeminiModel 기본 Gemini 임베딩 모델
+const defaultGeminiModel = "gemini-embedding-001"
+
+// geminiMaxBatch BatchEmbedContents 요청 하나에 담을 수 있는 최대 텍스트 수
+const geminiMaxBatch = 100
+
+// GeminiEmbedder Gemini API를 사용하여 텍스트를 임베딩으로 변환하는 구조체
+// 호출마다 task type을 설정한 모델을 새로 만들므로 여러 고루틴에서 함께 사용할 수 있습니다
+type GeminiEmbedder struct {
+	client    *genai.Client
+	modelName string
+	modelID   string
+	batchSize int
+	dimension atomic.Int64
 }
 
-// NewEmbedder 새로운 임베딩 생성기를 생성합니다
-func NewEmbedder(ctx context.Context, apiKey string) (*Embedder, error) {
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+// NewGeminiEmbedder 새로운 Gemini 임베딩 생성기를 생성합니다
+func NewGeminiEmbedder(ctx context.Context, cfg Config) (*GeminiEmbedder, error) {
+	// base_url을 지정하면 기본 API 주소 대신 사용 (프록시 등)
+	opts := []option.ClientOption{option.WithAPIKey(cfg.APIKey)}
+	if cfg.BaseURL != "" {
+		opts = append(opts, option.WithEndpoint(cfg.BaseURL))
+	}
+
+	client, err := genai.NewClient(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("Gemini 클라이언트 생성 실패: %w", err)
 	}
 
-	model := client.EmbeddingModel("gemini-embedding-001")
+	modelName := cfg.Model
+	if modelName == "" {
+		modelName = defaultGeminiModel
+	}
+
+	e := &GeminiEmbedder{
+		client:    client,
+		modelName: modelName,
+		modelID:   cfg.modelID(ProviderGemini, modelName),
+		batchSize: cfg.batchSize(geminiMaxBatch),
+	}
+	e.dimension.Store(int64(cfg.Dimension))
 
-	return &Embedder{
-		client: client,
-		model:  model,
-		ctx:    ctx,
-	}, nil
+	return e, nil
 }
 
 // EmbedText 텍스트를 임베딩 벡터로 변환합니다
 // taskType: "RETRIEVAL_DOCUMENT" (저장 시) 또는 "RETRIEVAL_QUERY" (검색 시)
 // Rate Limit 에러 발생 시 30초 대기 후 재시도합니다
-func (e *Embedder) EmbedText(text string, taskType string) ([]float32, error) {
-	const maxRetries = 3
-	const retryDelay = 30 * time.Second
-
-	// TaskType 상수 변환
-	var taskTypeEnum genai.TaskType
-	switch taskType {
-	case "RETRIEVAL_DOCUMENT":
-		taskTypeEnum = genai.TaskTypeRetrievalDocument
-	case "RETRIEVAL_QUERY":
-		taskTypeEnum = genai.TaskTypeRetrievalQuery
-	default:
-		taskTypeEnum = genai.TaskTypeUnspecified
-	}
+func (e *GeminiEmbedder) EmbedText(ctx context.Context, text string, taskType string) ([]float32, error) {
+	model := e.embeddingModel(taskType)
 
-	// 기존 TaskType 저장
-	originalTaskType := e.model.TaskType
-	// TaskType 설정
-	e.model.TaskType = taskTypeEnum
-	defer func() {
-		// 원래 TaskType 복원
-		e.model.TaskType = originalTaskType
-	}()
-
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
+	return retry.Do(ctx, func() ([]float32, error) {
 		// EmbedContent 호출
-		resp, err := e.model.EmbedContent(e.ctx, genai.Text(text))
-		if err == nil {
-			// 성공 시 응답 처리
-			if resp.Embedding == nil {
-				return nil, fmt.Errorf("임베딩 응답이 비어있습니다")
-			}
+		resp, err := model.EmbedContent(ctx, genai.Text(text))
+		if err != nil {
+			return nil, err
+		}
 
-			// float64를 float32로 변환
-			values := resp.Embedding.Values
-			result := make([]float32, len(values))
-			for i, v := range values {
-				result[i] = float32(v)
-			}
+		if resp.Embedding == nil {
+			return nil, fmt.Errorf("임베딩 응답이 비어있습니다")
+		}
 
-			return result, nil
+		// float64를 float32로 변환
+		values := resp.Embedding.Values
+		result := make([]float32, len(values))
+		for i, v := range values {
+			result[i] = float32(v)
 		}
 
-		lastErr = err
-		errStr := err.Error()
+		e.dimension.CompareAndSwap(0, int64(len(result)))
+		return result, nil
+	})
+}
+
+// EmbedTexts 여러 텍스트를 BatchEmbedContents로 배치 임베딩합니다 (결과는 입력과 같은 순서)
+// 배치 크기를 넘는 입력은 여러 요청으로 나누어 보냅니다
+// taskType: "RETRIEVAL_DOCUMENT" (저장 시) 또는 "RETRIEVAL_QUERY" (검색 시)
+func (e *GeminiEmbedder) EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
 
-		// Rate Limit 에러 확인 (429 또는 rate limit 관련 메시지)
-		isRateLimit := strings.Contains(errStr, "429") ||
-			strings.Contains(strings.ToLower(errStr), "rate limit") ||
-			strings.Contains(strings.ToLower(errStr), "quota") ||
-			strings.Contains(strings.ToLower(errStr), "resource exhausted")
+	// NewBatch가 모델의 TaskType을 복사하므로 task type을 설정한 모델에서 배치 생성
+	model := e.embeddingModel(taskType)
 
-		if isRateLimit && attempt < maxRetries-1 {
-			fmt.Printf("⚠️  Rate Limit 에러 발생 (시도 %d/%d), %v 후 재시도...\n", attempt+1, maxRetries, retryDelay)
-			time.Sleep(retryDelay)
-			continue
+	results := make([][]float32, 0, len(texts))
+	for _, r := range splitBatches(len(texts), e.batchSize) {
+		batch := model.NewBatch()
+		for _, text := range texts[r[0]:r[1]] {
+			batch.AddContent(genai.Text(text))
 		}
 
-		// Rate Limit이 아니거나 최대 재시도 횟수에 도달한 경우
-		return nil, fmt.Errorf("임베딩 생성 실패: %w", err)
-	}
-
-	return nil, fmt.Errorf("최대 재시도 횟수 초과: %w", lastErr)
-}
+		vectors, err := retry.Do(ctx, func() ([][]float32, error) {
+			resp, err := model.BatchEmbedContents(ctx, batch)
+			if err != nil {
+				return nil, err
+			}
 
-// EmbedTexts 여러 텍스트를 배치로 임베딩합니다 (Rate limit 방지)
-// taskType: "RETRIEVAL_DOCUMENT" (저장 시) 또는 "RETRIEVAL_QUERY" (검색 시)
-func (e *Embedder) EmbedTexts(texts []string, taskType string) ([][]float32, error) {
-	var results [][]float32
+			if len(resp.Embeddings) != r[1]-r[0] {
+				return nil, fmt.Errorf("임베딩 응답 개수가 맞지 않습니다 (요청 %d개, 응답 %d개)", r[1]-r[0], len(resp.Embeddings))
+			}
 
-	for i, text := range texts {
-		embedding, err := e.EmbedText(text, taskType)
+			vectors := make([][]float32, len(resp.Embeddings))
+			for i, emb := range resp.Embeddings {
+				if emb == nil {
+					return nil, fmt.Errorf("임베딩 응답이 비어있습니다 (텍스트 %d)", r[0]+i)
+				}
+				vectors[i] = emb.Values
+			}
+			return vectors, nil
+		})
 		if err != nil {
-			return nil, fmt.Errorf("텍스트 %d 임베딩 실패: %w", i, err)
+			return nil, fmt.Errorf("텍스트 %d~%d 배치 임베딩 실패: %w", r[0], r[1]-1, err)
 		}
-		results = append(results, embedding)
 
-		// Rate limit 방지를 위한 짧은 대기
-		if i < len(texts)-1 {
-			time.Sleep(100 * time.Millisecond)
-		}
+		e.dimension.CompareAndSwap(0, int64(len(vectors[0])))
+		results = append(results, vectors...)
 	}
 
 	return results, nil
 }
 
+// Dimension 임베딩 벡터 차원 수를 반환합니다
+func (e *GeminiEmbedder) Dimension() int {
+	return int(e.dimension.Load())
+}
+
+// ModelID 모델 식별자를 반환합니다
+func (e *GeminiEmbedder) ModelID() string {
+	return e.modelID
+}
+
 // Close 클라이언트를 닫습니다
-func (e *Embedder) Close() error {
+func (e *GeminiEmbedder) Close() error {
 	return e.client.Close()
 }
+
+// embeddingModel task type을 설정한 임베딩 모델을 생성합니다
+// 공유 모델의 TaskType을 바꾸지 않으므로 동시에 다른 task type으로 호출해도 안전합니다
+func (e *GeminiEmbedder) embeddingModel(taskType string) *genai.EmbeddingModel {
+	model := e.client.EmbeddingModel(e.modelName)
+	model.TaskType = geminiTaskType(taskType)
+	return model
+}
+
+// geminiTaskType task type 문자열을 genai 상수로 변환합니다
+func geminiTaskType(taskType string) genai.TaskType {
+	switch taskType {
+	case TaskRetrievalDocument:
+		return genai.TaskTypeRetrievalDocument
+	case TaskRetrievalQuery:
+		return genai.TaskTypeRetrievalQuery
+	default:
+		return genai.TaskTypeUnspecified
+	}
+}
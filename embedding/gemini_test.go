@@ -0,0 +1,181 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// geminiTaskValues 가짜 서버가 task type마다 돌려주는 벡터 첫 값 (REST 요청의 taskType enum 값)
+var geminiTaskValues = map[string]float32{
+	TaskRetrievalQuery:    1,
+	TaskRetrievalDocument: 2,
+}
+
+// newFakeGeminiServer embedContent/batchEmbedContents 요청마다 [taskType, 텍스트 길이] 벡터를 돌려주는 테스트 서버
+func newFakeGeminiServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	type content struct {
+		Parts []struct {
+			Text string `json:"text"`
+		} `json:"parts"`
+	}
+	type embedRequest struct {
+		Content  content `json:"content"`
+		TaskType int     `json:"taskType"`
+	}
+	vector := func(req embedRequest) map[string]any {
+		text := ""
+		if len(req.Content.Parts) > 0 {
+			text = req.Content.Parts[0].Text
+		}
+		return map[string]any{"values": []float32{float32(req.TaskType), float32(len(text))}}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, ":embedContent"):
+			var req embedRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"embedding": vector(req)})
+		case strings.HasSuffix(r.URL.Path, ":batchEmbedContents"):
+			var req struct {
+				Requests []embedRequest `json:"requests"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			embeddings := make([]map[string]any, len(req.Requests))
+			for i, item := range req.Requests {
+				embeddings[i] = vector(item)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"embeddings": embeddings})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// checkVector 벡터가 요청한 task type과 텍스트로 만들어졌는지 확인합니다
+func checkVector(vector []float32, taskType, text string) error {
+	if len(vector) != 2 {
+		return fmt.Errorf("벡터 길이 %d, 기대값 2", len(vector))
+	}
+	if vector[0] != geminiTaskValues[taskType] {
+		return fmt.Errorf("%q: task type 값 %v, 기대값 %v (%s)", text, vector[0], geminiTaskValues[taskType], taskType)
+	}
+	if vector[1] != float32(len(text)) {
+		return fmt.Errorf("%q: 텍스트 길이 %v, 기대값 %d", text, vector[1], len(text))
+	}
+	return nil
+}
+
+// TestGeminiEmbedderConcurrent 여러 고루틴에서 서로 다른 task type으로 EmbedText/EmbedTexts를 동시에 호출해도
+// 각 요청이 자신의 task type으로 전송되는지 확인합니다 (go test -race로 실행)
+func TestGeminiEmbedderConcurrent(t *testing.T) {
+	server := newFakeGeminiServer(t)
+	defer server.Close()
+
+	embedder, err := NewGeminiEmbedder(context.Background(), Config{APIKey: "test", BaseURL: server.URL, BatchSize: 3})
+	if err != nil {
+		t.Fatalf("임베딩 생성기 생성 실패: %v", err)
+	}
+	defer embedder.Close()
+
+	runConcurrentEmbeds(t, embedder)
+
+	if got := embedder.Dimension(); got != 2 {
+		t.Errorf("Dimension() = %d, 기대값 2", got)
+	}
+}
+
+// TestCachedEmbedderConcurrent 캐시를 감싼 임베딩 생성기를 동시에 호출해도 캐시와 결과가 어긋나지 않는지 확인합니다
+func TestCachedEmbedderConcurrent(t *testing.T) {
+	server := newFakeGeminiServer(t)
+	defer server.Close()
+
+	gemini, err := NewGeminiEmbedder(context.Background(), Config{APIKey: "test", BaseURL: server.URL, BatchSize: 3})
+	if err != nil {
+		t.Fatalf("임베딩 생성기 생성 실패: %v", err)
+	}
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "embedding_cache.gob"))
+	if err != nil {
+		t.Fatalf("캐시 열기 실패: %v", err)
+	}
+	embedder := WithCache(gemini, cache)
+	defer embedder.Close()
+
+	// 같은 텍스트를 두 번 돌려서 두 번째는 캐시에서 읽도록 함
+	runConcurrentEmbeds(t, embedder)
+	runConcurrentEmbeds(t, embedder)
+
+	if cache.Hits() == 0 {
+		t.Error("캐시 적중이 없습니다")
+	}
+}
+
+// runConcurrentEmbeds 고루틴마다 task type을 번갈아 EmbedText와 EmbedTexts를 호출하고 결과를 확인합니다
+func runConcurrentEmbeds(t *testing.T, embedder Embedder) {
+	t.Helper()
+
+	const workers = 16
+	taskTypes := []string{TaskRetrievalQuery, TaskRetrievalDocument}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*2)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			taskType := taskTypes[w%len(taskTypes)]
+
+			text := strings.Repeat("x", w+1)
+			vector, err := embedder.EmbedText(context.Background(), text, taskType)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := checkVector(vector, taskType, text); err != nil {
+				errs <- err
+			}
+
+			// 배치 크기(3)를 넘겨서 여러 요청으로 나뉘도록 함
+			texts := make([]string, 7)
+			for i := range texts {
+				texts[i] = strings.Repeat("y", w*10+i+1)
+			}
+			vectors, err := embedder.EmbedTexts(context.Background(), texts, taskType)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if len(vectors) != len(texts) {
+				errs <- fmt.Errorf("결과 %d개, 기대값 %d개", len(vectors), len(texts))
+				return
+			}
+			for i, vector := range vectors {
+				if err := checkVector(vector, taskType, texts[i]); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
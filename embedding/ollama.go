@@ -0,0 +1,121 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"goc-notion-rag/internal/httpx"
+	"goc-notion-rag/internal/retry"
+)
+
+// defaultOllamaBaseURL 기본 Ollama 서버 주소
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaEmbedder Ollama 스타일 /api/embed 엔드포인트를 사용하는 로컬 임베딩 생성기
+type OllamaEmbedder struct {
+	httpClient *http.Client
+	baseURL    string
+	modelName  string
+	modelID    string
+	batchSize  int
+	dimension  atomic.Int64
+}
+
+// ollamaRequest /api/embed 요청 본문
+type ollamaRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaResponse /api/embed 응답 본문
+type ollamaResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// NewOllamaEmbedder 새로운 Ollama 임베딩 생성기를 생성합니다
+func NewOllamaEmbedder(ctx context.Context, cfg Config) (*OllamaEmbedder, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("ollama 임베딩 제공자는 model 설정이 필요합니다")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+
+	e := &OllamaEmbedder{
+		httpClient: httpx.NewClient(),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		modelName:  cfg.Model,
+		modelID:    cfg.modelID(ProviderOllama, cfg.Model),
+		batchSize:  cfg.batchSize(0),
+	}
+	e.dimension.Store(int64(cfg.Dimension))
+
+	return e, nil
+}
+
+// EmbedText 텍스트를 임베딩 벡터로 변환합니다
+// Ollama API는 task type을 구분하지 않으므로 taskType은 무시됩니다
+func (e *OllamaEmbedder) EmbedText(ctx context.Context, text string, taskType string) ([]float32, error) {
+	vectors, err := e.EmbedTexts(ctx, []string{text}, taskType)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedTexts 여러 텍스트를 배치 크기 단위의 요청으로 나누어 임베딩합니다
+func (e *OllamaEmbedder) EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for _, r := range splitBatches(len(texts), e.batchSize) {
+		vectors, err := e.embedBatch(ctx, texts[r[0]:r[1]])
+		if err != nil {
+			return nil, fmt.Errorf("텍스트 %d~%d 배치 임베딩 실패: %w", r[0], r[1]-1, err)
+		}
+		results = append(results, vectors...)
+	}
+
+	return results, nil
+}
+
+// embedBatch 텍스트 묶음 하나를 한 번의 요청으로 임베딩합니다
+func (e *OllamaEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return retry.Do(ctx, func() ([][]float32, error) {
+		var resp ollamaResponse
+		req := ollamaRequest{Model: e.modelName, Input: texts}
+		if err := httpx.PostJSON(ctx, e.httpClient, e.baseURL+"/api/embed", "", req, &resp); err != nil {
+			return nil, err
+		}
+
+		if len(resp.Embeddings) != len(texts) {
+			return nil, fmt.Errorf("임베딩 응답 개수가 맞지 않습니다 (요청 %d개, 응답 %d개)", len(texts), len(resp.Embeddings))
+		}
+
+		e.dimension.CompareAndSwap(0, int64(len(resp.Embeddings[0])))
+		return resp.Embeddings, nil
+	})
+}
+
+// Dimension 임베딩 벡터 차원 수를 반환합니다
+func (e *OllamaEmbedder) Dimension() int {
+	return int(e.dimension.Load())
+}
+
+// ModelID 모델 식별자를 반환합니다
+func (e *OllamaEmbedder) ModelID() string {
+	return e.modelID
+}
+
+// Close 리소스를 정리합니다
+func (e *OllamaEmbedder) Close() error {
+	e.httpClient.CloseIdleConnections()
+	return nil
+}
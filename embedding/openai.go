@@ -0,0 +1,143 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"goc-notion-rag/internal/httpx"
+	"goc-notion-rag/internal/retry"
+)
+
+// defaultOpenAIBaseURL 기본 OpenAI API 주소
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIEmbedder OpenAI 호환 /embeddings 엔드포인트를 사용하는 임베딩 생성기
+// OpenAI 외에도 같은 API를 제공하는 서버(vLLM, LM Studio 등)에서 사용할 수 있습니다
+type OpenAIEmbedder struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	modelName  string
+	modelID    string
+	batchSize  int
+	dimension  atomic.Int64
+}
+
+// openAIRequest /embeddings 요청 본문
+type openAIRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// openAIResponse /embeddings 응답 본문
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// NewOpenAIEmbedder 새로운 OpenAI 호환 임베딩 생성기를 생성합니다
+func NewOpenAIEmbedder(ctx context.Context, cfg Config) (*OpenAIEmbedder, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("openai 임베딩 제공자는 model 설정이 필요합니다")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+
+	e := &OpenAIEmbedder{
+		httpClient: httpx.NewClient(),
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		modelName:  cfg.Model,
+		modelID:    cfg.modelID(ProviderOpenAI, cfg.Model),
+		batchSize:  cfg.batchSize(0),
+	}
+	e.dimension.Store(int64(cfg.Dimension))
+
+	return e, nil
+}
+
+// EmbedText 텍스트를 임베딩 벡터로 변환합니다
+// OpenAI API는 task type을 구분하지 않으므로 taskType은 무시됩니다
+func (e *OpenAIEmbedder) EmbedText(ctx context.Context, text string, taskType string) ([]float32, error) {
+	vectors, err := e.EmbedTexts(ctx, []string{text}, taskType)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedTexts 여러 텍스트를 배치 크기 단위의 요청으로 나누어 임베딩합니다
+func (e *OpenAIEmbedder) EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for _, r := range splitBatches(len(texts), e.batchSize) {
+		vectors, err := e.embedBatch(ctx, texts[r[0]:r[1]])
+		if err != nil {
+			return nil, fmt.Errorf("텍스트 %d~%d 배치 임베딩 실패: %w", r[0], r[1]-1, err)
+		}
+		results = append(results, vectors...)
+	}
+
+	return results, nil
+}
+
+// embedBatch 텍스트 묶음 하나를 한 번의 요청으로 임베딩합니다
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	return retry.Do(ctx, func() ([][]float32, error) {
+		var resp openAIResponse
+		req := openAIRequest{Model: e.modelName, Input: texts}
+		if err := httpx.PostJSON(ctx, e.httpClient, e.baseURL+"/embeddings", e.apiKey, req, &resp); err != nil {
+			return nil, err
+		}
+
+		if len(resp.Data) != len(texts) {
+			return nil, fmt.Errorf("임베딩 응답 개수가 맞지 않습니다 (요청 %d개, 응답 %d개)", len(texts), len(resp.Data))
+		}
+
+		// 응답 순서가 요청 순서와 다를 수 있으므로 index 기준으로 정렬
+		// 개수가 같고 index가 겹치지 않으면 모든 자리가 채워짐
+		results := make([][]float32, len(texts))
+		for _, item := range resp.Data {
+			if item.Index < 0 || item.Index >= len(texts) {
+				return nil, fmt.Errorf("잘못된 임베딩 응답 index: %d", item.Index)
+			}
+			if results[item.Index] != nil {
+				return nil, fmt.Errorf("임베딩 응답 index가 중복되었습니다: %d", item.Index)
+			}
+			if len(item.Embedding) == 0 {
+				return nil, fmt.Errorf("임베딩 응답이 비어있습니다 (index %d)", item.Index)
+			}
+			results[item.Index] = item.Embedding
+		}
+
+		e.dimension.CompareAndSwap(0, int64(len(results[0])))
+		return results, nil
+	})
+}
+
+// Dimension 임베딩 벡터 차원 수를 반환합니다
+func (e *OpenAIEmbedder) Dimension() int {
+	return int(e.dimension.Load())
+}
+
+// ModelID 모델 식별자를 반환합니다
+func (e *OpenAIEmbedder) ModelID() string {
+	return e.modelID
+}
+
+// Close 리소스를 정리합니다
+func (e *OpenAIEmbedder) Close() error {
+	e.httpClient.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,74 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestOpenAIEmbedderResponseIndex 응답의 index가 범위를 벗어나거나 겹치거나 벡터가 비어있으면 에러를 반환하는지 확인합니다
+func TestOpenAIEmbedderResponseIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"순서 뒤바뀜", `[{"index":1,"embedding":[2]},{"index":0,"embedding":[1]}]`, false},
+		{"index 중복", `[{"index":0,"embedding":[1]},{"index":0,"embedding":[2]}]`, true},
+		{"index 범위 초과", `[{"index":0,"embedding":[1]},{"index":2,"embedding":[2]}]`, true},
+		{"빈 벡터", `[{"index":0,"embedding":[1]},{"index":1,"embedding":[]}]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"data":%s}`, tt.data)
+			}))
+			defer server.Close()
+
+			e, err := NewOpenAIEmbedder(context.Background(), Config{Provider: ProviderOpenAI, Model: "test", BaseURL: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			vectors, err := e.EmbedTexts(context.Background(), []string{"a", "b"}, TaskRetrievalDocument)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("에러를 기대했지만 결과를 받았습니다: %v", vectors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vectors[0][0] != 1 || vectors[1][0] != 2 {
+				t.Errorf("index 순서로 정렬되지 않았습니다: %v", vectors)
+			}
+		})
+	}
+}
+
+// TestModelIDDimension 설정한 차원 수가 ModelID(캐시 키)에 들어가서 차원을 바꾸면 이전 캐시를 쓰지 않는지 확인합니다
+func TestModelIDDimension(t *testing.T) {
+	ctx := context.Background()
+	without, err := New(ctx, Config{Provider: ProviderOllama, Model: "bge-m3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	with, err := New(ctx, Config{Provider: ProviderOllama, Model: "bge-m3", Dimension: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := without.ModelID(); got != "ollama/bge-m3" {
+		t.Errorf("차원 수를 설정하지 않은 ModelID = %q, 기대 %q", got, "ollama/bge-m3")
+	}
+	if got := with.ModelID(); got != "ollama/bge-m3@1024" {
+		t.Errorf("차원 수를 설정한 ModelID = %q, 기대 %q", got, "ollama/bge-m3@1024")
+	}
+	if CacheKey(without.ModelID(), TaskRetrievalDocument, "본문") == CacheKey(with.ModelID(), TaskRetrievalDocument, "본문") {
+		t.Error("차원 수가 달라도 캐시 키가 같습니다")
+	}
+}
@@ -0,0 +1,123 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 토크나이저 종류
+const (
+	TokenizerEstimate = "estimate" // 문자 종류별 근사치 (기본값)
+	TokenizerRunes    = "runes"    // 문자 하나를 토큰 하나로 계산
+)
+
+// 제공자별 임베딩 모델의 기본 최대 입력 토큰 수
+const (
+	geminiMaxInputTokens = 2048
+	openAIMaxInputTokens = 8191
+)
+
+// Tokenizer 텍스트의 토큰 수를 계산하는 인터페이스
+// 정확한 토크나이저 대신 근사치를 사용해도 되지만 실제보다 적게 세지 않는 편이 안전합니다
+type Tokenizer interface {
+	// CountTokens 텍스트의 토큰 수를 반환합니다
+	CountTokens(text string) int
+}
+
+// NewTokenizer 이름에 맞는 토크나이저를 생성합니다 (빈 문자열이면 TokenizerEstimate)
+func NewTokenizer(name string) (Tokenizer, error) {
+	switch name {
+	case "", TokenizerEstimate:
+		return EstimateTokenizer{}, nil
+	case TokenizerRunes:
+		return RuneTokenizer{}, nil
+	default:
+		return nil, fmt.Errorf("지원하지 않는 토크나이저입니다: %s", name)
+	}
+}
+
+// EstimateTokenizer 문자 종류별로 토큰 수를 근사하는 토크나이저
+// 영문·숫자 단어는 4글자당 1토큰, 한글·한자·가나와 기호는 글자당 1토큰으로 계산합니다
+type EstimateTokenizer struct{}
+
+// CountTokens 텍스트의 근사 토큰 수를 반환합니다
+func (EstimateTokenizer) CountTokens(text string) int {
+	tokens := 0
+	word := 0 // 이어지는 영문·숫자 글자 수
+
+	flushWord := func() {
+		tokens += (word + 3) / 4
+		word = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word++
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			// 한글·한자·가나와 기호는 글자당 1토큰
+			flushWord()
+			tokens++
+		}
+	}
+	flushWord()
+
+	return tokens
+}
+
+// RuneTokenizer 문자 하나를 토큰 하나로 계산하는 토크나이저 (문자 단위 청킹과 같은 동작)
+type RuneTokenizer struct{}
+
+// CountTokens 텍스트의 문자 수를 반환합니다
+func (RuneTokenizer) CountTokens(text string) int {
+	return len([]rune(text))
+}
+
+// TruncateTokens 텍스트가 maxTokens 이하가 되도록 뒤쪽을 잘라냅니다
+// 잘라냈으면 true를 반환하며, 가능하면 공백 경계에서 자릅니다
+func TruncateTokens(tok Tokenizer, text string, maxTokens int) (string, bool) {
+	if maxTokens <= 0 || tok.CountTokens(text) <= maxTokens {
+		return text, false
+	}
+
+	prefix := PrefixTokens(tok, text, maxTokens)
+	if idx := strings.LastIndexFunc(prefix, unicode.IsSpace); idx > len(prefix)/2 {
+		prefix = prefix[:idx]
+	}
+	return prefix, true
+}
+
+// PrefixTokens 토큰 수가 maxTokens 이하인 가장 긴 앞부분을 반환합니다 (문자 단위 이진 탐색)
+func PrefixTokens(tok Tokenizer, text string, maxTokens int) string {
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if tok.CountTokens(string(runes[:mid])) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo])
+}
+
+// MaxInputTokens 임베딩 모델의 최대 입력 토큰 수를 반환합니다 (0이면 제한 없음)
+// max_tokens를 설정하지 않으면 제공자별 기본값을 사용합니다
+func (c Config) MaxInputTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	switch c.Provider {
+	case "", ProviderGemini:
+		return geminiMaxInputTokens
+	case ProviderOpenAI:
+		return openAIMaxInputTokens
+	default:
+		// Ollama 모델은 모델마다 한도가 달라 설정값이 없으면 제한하지 않음
+		return 0
+	}
+}
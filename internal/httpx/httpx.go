@@ -0,0 +1,59 @@
+// Package httpx 임베딩·재순위 제공자가 공통으로 사용하는 JSON HTTP 요청
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Timeout HTTP 요청 타임아웃
+const Timeout = 2 * time.Minute
+
+// NewClient Timeout을 적용한 HTTP 클라이언트를 생성합니다
+func NewClient() *http.Client {
+	return &http.Client{Timeout: Timeout}
+}
+
+// PostJSON JSON 요청을 보내고 응답을 out에 디코딩합니다
+// apiKey가 있으면 Bearer 인증 헤더를 붙이며, 2xx가 아닌 응답은 상태 코드를 포함한 에러로 반환합니다 (429는 재시도 대상)
+func PostJSON(ctx context.Context, client *http.Client, url, apiKey string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("요청 직렬화 실패: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("요청 생성 실패: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("요청 실패: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("응답 읽기 실패: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+
+	return nil
+}
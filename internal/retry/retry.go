@@ -0,0 +1,57 @@
+// Package retry Gemini·HTTP API 호출에 공통으로 사용하는 Rate Limit 재시도 정책
+package retry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 재시도 정책 (Rate Limit 에러 발생 시 30초 대기, 최대 3회 시도)
+const (
+	MaxAttempts = 3
+	Delay       = 30 * time.Second
+)
+
+// IsRateLimit Rate Limit 에러인지 확인합니다 (429 또는 rate limit 관련 메시지)
+func IsRateLimit(err error) bool {
+	errStr := strings.ToLower(err.Error())
+	return strings.Contains(errStr, "429") ||
+		strings.Contains(errStr, "rate limit") ||
+		strings.Contains(errStr, "quota") ||
+		strings.Contains(errStr, "resource exhausted")
+}
+
+// Wait attempt번째 시도(0부터)가 Rate Limit으로 실패했음을 알리고 Delay만큼 기다립니다
+// 기다리는 중 ctx가 취소되면 ctx.Err()를 반환합니다
+func Wait(ctx context.Context, attempt int) error {
+	fmt.Printf("⚠️  Rate Limit 에러 발생 (시도 %d/%d), %v 후 재시도...\n", attempt+1, MaxAttempts, Delay)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(Delay):
+		return nil
+	}
+}
+
+// Do call을 실행하고 Rate Limit 에러면 Delay만큼 기다린 뒤 최대 MaxAttempts번까지 시도합니다
+// Rate Limit이 아닌 에러는 그대로 반환하며, 기다리는 중 ctx가 취소되면 ctx.Err()를 반환합니다
+func Do[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	var zero T
+	for attempt := 0; ; attempt++ {
+		result, err := call()
+		if err == nil {
+			return result, nil
+		}
+		if !IsRateLimit(err) {
+			return zero, err
+		}
+		if attempt == MaxAttempts-1 {
+			return zero, fmt.Errorf("최대 재시도 횟수 초과: %w", err)
+		}
+		if err := Wait(ctx, attempt); err != nil {
+			return zero, err
+		}
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,16 +18,38 @@ import (
 	"goc-notion-rag/models"
 	"goc-notion-rag/notion"
 	"goc-notion-rag/rag"
+	"goc-notion-rag/rerank"
 	"goc-notion-rag/ui"
+
+	"github.com/jomei/notionapi"
 )
 
 func main() {
 	// 플래그 파싱
 	reload := flag.Bool("reload", false, "Notion 데이터를 새로 가져옵니다")
+	syncMode := flag.Bool("sync", false, "변경된 Notion 페이지만 다시 가져옵니다 (증분 동기화)")
 	workers := flag.Int("workers", 5, "Gemini 임베딩 처리 워커 수 (기본값: 5)")
-	list := flag.Bool("list", false, "저장된 문서 목록 보기 (제목으로 검색)")
+	list := flag.Bool("list", false, "저장된 페이지 목록 보기 (--title, --sort, --page와 함께 사용)")
+	listTitle := flag.String("title", "", "--list: 제목에 포함된 문자열로 필터링")
+	listSort := flag.String("sort", "title", "--list: 정렬 기준 (title, last_edit, created, chunks, 앞에 '-'를 붙이면 내림차순)")
+	listPage := flag.Int("page", 1, "--list: 페이지 번호")
+	listPageSize := flag.Int("page-size", 20, "--list: 한 페이지에 표시할 개수")
 	show := flag.String("show", "", "특정 문서 ID로 내용 보기")
 	searchText := flag.String("search", "", "텍스트로 문서 검색 (임베딩 검색)")
+	topK := flag.Int("top-k", 0, "검색할 최대 청크 수 (지정하지 않으면 config.json의 search.top_k)")
+	minScore := flag.Float64("min-score", 0, "최소 유사도 (지정하지 않으면 config.json의 search.min_similarity, 음수면 제한 없음)")
+	mode := flag.String("mode", "", "검색 방식: vector, keyword, hybrid (비어있으면 config.json의 search.mode)")
+	mmrLambda := flag.Float64("mmr", 0, "MMR 관련도 가중치 0~1 (지정하지 않으면 config.json의 search.mmr_lambda, 0이면 사용 안 함, 1에 가까울수록 관련도 우선)")
+	maxPerPage := flag.Int("max-per-page", 0, "한 페이지에서 가져올 최대 청크 수 (지정하지 않으면 config.json의 search.max_per_page, 0이면 제한 없음)")
+	expandNeighbors := flag.Int("expand", 0, "검색된 청크의 앞뒤로 함께 답변에 사용할 청크 수 (지정하지 않으면 config.json의 search.expand_neighbors, 0이면 사용 안 함)")
+	multiQuery := flag.Int("multi-query", 0, "답변 전에 함께 검색할 추가 검색 질문 수 (지정하지 않으면 config.json의 search.multi_query, 0이면 사용 안 함)")
+	prompt := flag.String("prompt", "", "답변 프롬프트 프리셋 이름 (비어있으면 config.json의 search.prompt)")
+	contextTokens := flag.Int("context-tokens", 0, "프롬프트 컨텍스트에 넣을 문서의 최대 토큰 수 (지정하지 않으면 config.json의 search.context_tokens, 음수면 제한 없음)")
+	hyde := flag.Bool("hyde", false, "질문 대신 Gemini로 만든 가상 답변 문서의 임베딩으로 벡터 검색 (HyDE, 지정하지 않으면 config.json의 search.hyde, --hyde=false로 끄기)")
+	hydeWeight := flag.Float64("hyde-weight", 0, "HyDE 벡터에 섞을 질문 벡터의 비율 0~1 (지정하지 않으면 config.json의 search.hyde_query_weight, 0이면 가상 문서 벡터만 사용)")
+	rerankProvider := flag.String("rerank", "", "검색 결과 재순위 제공자: gemini, http, none (비어있으면 config.json의 search.rerank.provider)")
+	filterExpr := flag.String("filter", "", "검색 범위 필터 (예: \"title~회의록, last_edit>=2025-01-01\")")
+	pruneCache := flag.Bool("prune-cache", false, "DB에 저장된 청크가 참조하지 않는 임베딩 캐시 항목을 삭제합니다")
 	flag.Parse()
 
 	ctx := context.Background()
@@ -35,6 +60,53 @@ func main() {
 		log.Fatalf("설정 로드 실패: %v", err)
 	}
 
+	// 플래그로 지정한 검색 옵션이 설정 파일보다 우선
+	// 명시한 플래그만 적용하므로 --mmr 0, --hyde=false처럼 기본값과 같은 값으로도 설정을 덮어쓸 수 있음
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "top-k":
+			config.Search.TopK = *topK
+		case "min-score":
+			config.Search.MinSimilarity = float32(*minScore)
+			// 설정의 0은 DefaultMinSimilarity를 뜻하므로 --min-score 0은 가장 작은 양수로 바꿔서 0 이상인 결과를 모두 사용
+			if *minScore == 0 {
+				config.Search.MinSimilarity = math.SmallestNonzeroFloat32
+			}
+		case "mode":
+			config.Search.Mode = *mode
+		case "mmr":
+			config.Search.MMRLambda = float32(*mmrLambda)
+		case "max-per-page":
+			config.Search.MaxPerPage = *maxPerPage
+		case "expand":
+			config.Search.ExpandNeighbors = *expandNeighbors
+		case "multi-query":
+			config.Search.MultiQuery = *multiQuery
+		case "prompt":
+			config.Search.Prompt = *prompt
+		case "context-tokens":
+			config.Search.ContextTokens = *contextTokens
+		case "hyde":
+			config.Search.HyDE = *hyde
+		case "hyde-weight":
+			config.Search.HyDEQueryWeight = float32(*hydeWeight)
+		case "rerank":
+			config.Search.Rerank.Provider = *rerankProvider
+			if *rerankProvider == rerank.ProviderGemini && config.Search.Rerank.APIKey == "" {
+				config.Search.Rerank.APIKey = config.GeminiAPIKey
+			}
+		}
+	})
+	if _, err := rag.ParseMode(config.Search.Mode); err != nil {
+		log.Fatalf("설정 오류: %v", err)
+	}
+
+	// 검색 필터 파싱
+	filter, err := db.ParseFilter(*filterExpr)
+	if err != nil {
+		log.Fatalf("필터 파싱 실패: %v", err)
+	}
+
 	// DB 초기화
 	store, err := db.NewStore(config.DBPath)
 	if err != nil {
@@ -42,13 +114,24 @@ func main() {
 	}
 	defer store.Close()
 
+	// 임베딩 캐시 로드 (DB 디렉터리 안에 저장)
+	cache, err := embedding.OpenCache(embedding.CachePath(config.DBPath))
+	if err != nil {
+		log.Fatalf("임베딩 캐시 로드 실패: %v", err)
+	}
+	defer func() {
+		if err := cache.Save(); err != nil {
+			log.Printf("⚠️  임베딩 캐시 저장 실패: %v", err)
+		}
+	}()
+
 	// DB 존재 여부 및 문서 개수 확인
 	dbExists := db.Exists(config.DBPath)
 	count, _ := store.Count(ctx)
 
 	// 데이터 조회 모드
 	if *list {
-		showDocumentList(ctx, store, count)
+		showDocumentList(ctx, store, count, *listTitle, *listSort, *listPage, *listPageSize)
 		return
 	}
 
@@ -58,26 +141,77 @@ func main() {
 	}
 
 	if *searchText != "" {
-		searchDocuments(ctx, store, config.GeminiAPIKey, *searchText)
+		// --search는 검색만 하므로 Gemini를 사용하는 기능을 설정한 경우에만 필요
+		if err := config.requireGeminiKey(false); err != nil {
+			log.Fatalf("설정 오류: %v", err)
+		}
+		if err := ensureKeywordIndex(ctx, store); err != nil {
+			log.Fatalf("키워드 색인 생성 실패: %v", err)
+		}
+		searchDocuments(ctx, store, config.GeminiAPIKey, config.Embedding, cache, config.Search, filter, *searchText)
+		return
+	}
+
+	if *pruneCache {
+		if err := config.requireGeminiKey(false); err != nil {
+			log.Fatalf("설정 오류: %v", err)
+		}
+		if err := pruneEmbeddingCache(ctx, store, config.Embedding, cache); err != nil {
+			log.Fatalf("임베딩 캐시 정리 실패: %v", err)
+		}
 		return
 	}
 
-	// 리로드 모드 또는 DB가 비어있는 경우
-	if *reload || !dbExists || count == 0 {
-		if !*reload && (!dbExists || count == 0) {
+	// 동기화가 끝나면 REPL에서 답변을 생성하므로 오래 걸리는 동기화 전에 미리 확인
+	if err := config.requireGeminiKey(true); err != nil {
+		log.Fatalf("설정 오류: %v", err)
+	}
+
+	// 임베딩 생성기 초기화 (파이프라인 워커와 RAG 검색기가 함께 사용)
+	embedder, err := embedding.New(ctx, config.Embedding)
+	if err != nil {
+		log.Fatalf("임베딩 생성기 초기화 실패: %v", err)
+	}
+	embedder = embedding.WithCache(embedder, cache)
+	defer embedder.Close()
+
+	// 리로드/동기화 모드 또는 DB가 비어있는 경우
+	if *reload || *syncMode || !dbExists || count == 0 {
+		if !*reload && !*syncMode && (!dbExists || count == 0) {
 			fmt.Println("⚠️  DB가 없거나 비어있습니다. --reload 옵션으로 데이터를 생성해주세요.")
 			fmt.Println("   또는 --reload 플래그를 사용하여 자동으로 데이터를 가져옵니다.")
 			os.Exit(1)
 		}
 
-		fmt.Println("🔄 Notion에서 데이터를 가져오는 중...")
+		if *syncMode && !*reload {
+			fmt.Println("🔄 Notion에서 변경된 페이지를 동기화하는 중...")
+		} else {
+			fmt.Println("🔄 Notion에서 데이터를 가져오는 중...")
+		}
 		fmt.Printf("⚙️  워커 수: %d\n", *workers)
 
+		// 리로드는 기존 청크를 모두 지우고 다시 저장 (pages.json에 없는 이전 청크도 남지 않도록, 벡터는 임베딩 캐시에서 재사용)
+		if *reload && count > 0 {
+			if err := store.Clear(ctx); err != nil {
+				log.Fatalf("기존 DB 삭제 실패: %v", err)
+			}
+			fmt.Printf("🗑️  기존 청크 %d개를 삭제했습니다\n", count)
+		}
+
+		// 청커 초기화 (임베딩 모델의 토큰 수 기준으로 청크 크기 측정)
+		tokenizer, err := embedding.NewTokenizer(config.Embedding.Tokenizer)
+		if err != nil {
+			log.Fatalf("토크나이저 초기화 실패: %v", err)
+		}
+		chunker := notion.NewChunker(config.Chunking, tokenizer, config.Embedding.MaxInputTokens())
+		fmt.Printf("⚙️  청크 크기: %d토큰 (임베딩 입력 한도: %d토큰)\n", chunker.Size(), config.Embedding.MaxInputTokens())
+
 		// Notion 로더 초기화
-		loader := notion.NewLoader(config.NotionAPIKey)
+		loader := notion.NewLoader(config.NotionAPIKey, chunker)
 
 		// 파이프라인 패턴으로 처리
-		if err := processDocumentsPipeline(ctx, loader, config.GeminiAPIKey, store, *workers); err != nil {
+		incremental := *syncMode && !*reload
+		if err := processDocumentsPipeline(ctx, loader, embedder, config.Embedding, cache, store, *workers, incremental); err != nil {
 			log.Fatalf("문서 처리 실패: %v", err)
 		}
 
@@ -89,8 +223,13 @@ func main() {
 		fmt.Printf("⚡ 기존 로컬 DB를 로드했습니다. (총 %d개 문서)\n\n", finalCount)
 	}
 
+	// 키워드 색인이 저장된 청크와 맞지 않으면 다시 생성
+	if err := ensureKeywordIndex(ctx, store); err != nil {
+		log.Fatalf("키워드 색인 생성 실패: %v", err)
+	}
+
 	// RAG 검색기 초기화
-	searcher, err := rag.NewSearcher(ctx, config.GeminiAPIKey, store)
+	searcher, err := rag.NewSearcher(ctx, config.GeminiAPIKey, embedder, store, config.Search)
 	if err != nil {
 		log.Fatalf("RAG 검색기 초기화 실패: %v", err)
 	}
@@ -98,20 +237,63 @@ func main() {
 
 	// REPL 실행
 	fmt.Println("검색 모드로 진입합니다...")
-	if err := ui.Run(searcher); err != nil {
+	if err := ui.Run(searcher, filter); err != nil {
 		log.Fatalf("REPL 실행 실패: %v", err)
 	}
 }
 
 // processDocumentsPipeline 파이프라인 패턴으로 문서를 처리합니다
 // Notion Producer 고루틴과 Gemini Consumer 워커 풀을 동시에 실행합니다
+// incremental이 true이면 last_edit이 저장된 값과 같은 페이지는 건너뜁니다
+// embedder는 모든 워커가 함께 사용합니다 (캐시를 감싼 Embedder를 전달)
 func processDocumentsPipeline(
 	ctx context.Context,
 	loader *notion.Loader,
-	geminiAPIKey string,
+	embedder embedding.Embedder,
+	embedCfg embedding.Config,
+	cache *embedding.Cache,
 	store *db.Store,
 	workerCount int,
+	incremental bool,
 ) error {
+	// 전체 페이지 목록 조회 후 저장된 상태와 비교하여 분류
+	pages, err := loader.ListPages(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		targets        []notionapi.Page
+		newPages       int
+		updatedPages   int
+		unchangedPages int
+	)
+	livePages := make(map[string]bool, len(pages))
+	for _, page := range pages {
+		// 보관(archived)된 페이지는 삭제된 것으로 취급
+		if page.Archived {
+			continue
+		}
+		livePages[string(page.ID)] = true
+
+		stored, ok := store.GetPage(string(page.ID))
+		switch {
+		case !ok:
+			newPages++
+		case stored.LastEdit != notion.PageLastEdit(page):
+			updatedPages++
+		default:
+			unchangedPages++
+			if incremental {
+				continue
+			}
+		}
+		targets = append(targets, page)
+	}
+
+	fmt.Printf("📄 페이지 %d개 중 새 페이지 %d개, 변경된 페이지 %d개, 변경 없음 %d개\n",
+		len(pages), newPages, updatedPages, unchangedPages)
+
 	// 문서 채널 생성 (버퍼 크기는 워커 수의 2배)
 	docChan := make(chan *models.Document, workerCount*2)
 
@@ -123,6 +305,26 @@ func processDocumentsPipeline(
 		skippedCount   int64
 	)
 
+	// 청크 처리에 실패한 페이지 (동기화 상태를 기록하지 않고 다음 동기화 때 다시 처리)
+	var failedMu sync.Mutex
+	failedPages := make(map[string]bool)
+	markFailed := func(pageID string) {
+		failedMu.Lock()
+		failedPages[pageID] = true
+		failedMu.Unlock()
+	}
+
+	// 콘텐츠가 너무 짧아 건너뛴 청크 ID (같은 ID에 이전 동기화의 청크가 남아있으면 삭제)
+	var skippedMu sync.Mutex
+	var skippedIDs []string
+	markSkipped := func(doc *models.Document) {
+		skippedMu.Lock()
+		skippedIDs = append(skippedIDs, doc.ID)
+		skippedMu.Unlock()
+		atomic.AddInt64(&skippedCount, 1)
+		atomic.AddInt64(&processedCount, 1)
+	}
+
 	// 진행 상황 출력용 ticker
 	progressTicker := time.NewTicker(2 * time.Second)
 	defer progressTicker.Stop()
@@ -147,26 +349,20 @@ func processDocumentsPipeline(
 		}
 	}()
 
-	// 임베딩 생성기 풀 생성 (각 워커가 독립적인 임베딩 생성기 사용)
-	embedders := make([]*embedding.Embedder, workerCount)
-	for i := 0; i < workerCount; i++ {
-		embedder, err := embedding.NewEmbedder(ctx, geminiAPIKey)
-		if err != nil {
-			// 이미 생성된 임베딩 생성기 정리
-			for j := 0; j < i; j++ {
-				embedders[j].Close()
-			}
-			return fmt.Errorf("임베딩 생성기 초기화 실패: %w", err)
-		}
-		embedders[i] = embedder
+	// 이번 실행의 캐시 적중/미스만 집계하기 위해 시작 시점 값 기록
+	startHits, startMisses := cache.Hits(), cache.Misses()
+
+	// 워커마다 청크를 배치 크기만큼 모아서 한 번에 임베딩·저장
+	batchSize := embedCfg.EffectiveBatchSize()
+	fmt.Printf("⚙️  배치 크기: %d\n", batchSize)
+
+	// 임베딩 입력 한도를 넘는 텍스트는 잘라서 임베딩
+	tokenizer, err := embedding.NewTokenizer(embedCfg.Tokenizer)
+	if err != nil {
+		return err
 	}
-	defer func() {
-		for _, embedder := range embedders {
-			if embedder != nil {
-				embedder.Close()
-			}
-		}
-	}()
+	maxTokens := embedCfg.MaxInputTokens()
+	var truncatedCount int64
 
 	// Gemini Consumer 워커 풀 시작
 	var wg sync.WaitGroup
@@ -175,55 +371,64 @@ func processDocumentsPipeline(
 		go func(workerID int) {
 			defer wg.Done()
 
-			embedder := embedders[workerID]
-
-			for doc := range docChan {
-				// 콘텐츠 길이 확인
-				contentLen := len([]rune(doc.Content))
-				if contentLen < 50 {
-					atomic.AddInt64(&skippedCount, 1)
-					atomic.AddInt64(&processedCount, 1)
-					continue
+			// 배치 실패 시 배치에 포함된 모든 청크를 실패로 집계
+			failBatch := func(batch []*models.Document) {
+				for _, doc := range batch {
+					markFailed(doc.ParentPageID)
 				}
+				atomic.AddInt64(&errorCount, int64(len(batch)))
+				atomic.AddInt64(&processedCount, int64(len(batch)))
+			}
 
-				// 임베딩 생성 (제목 + 본문을 함께 임베딩하여 제목 기반 검색도 가능하도록)
-				embeddingText := doc.Content
-				if doc.Title != "" {
-					// 제목을 본문 앞에 추가하여 임베딩에 포함
-					embeddingText = doc.Title + "\n\n" + doc.Content
-				}
-				vector, err := embedder.EmbedText(embeddingText, "RETRIEVAL_DOCUMENT")
-				if err != nil {
-					log.Printf("⚠️  [워커 %d] 문서 %s 임베딩 실패: %v", workerID, doc.ID, err)
-					atomic.AddInt64(&errorCount, 1)
-					atomic.AddInt64(&processedCount, 1)
-					continue
+			for {
+				batch, ok := collectBatch(docChan, batchSize, markSkipped)
+				if len(batch) > 0 {
+					// 임베딩 생성 (제목 + 본문을 함께 임베딩하여 제목 기반 검색도 가능하도록)
+					texts := make([]string, len(batch))
+					for idx, doc := range batch {
+						text, truncated := embedding.TruncateTokens(tokenizer, embeddingText(doc), maxTokens)
+						if truncated {
+							atomic.AddInt64(&truncatedCount, 1)
+						}
+						texts[idx] = text
+					}
+
+					vectors, err := embedder.EmbedTexts(ctx, texts, embedding.TaskRetrievalDocument)
+					if err != nil {
+						log.Printf("⚠️  [워커 %d] 청크 %d개 배치 임베딩 실패: %v", workerID, len(batch), err)
+						failBatch(batch)
+					} else {
+						for idx, doc := range batch {
+							doc.Vector = vectors[idx]
+						}
+
+						// DB에 한 번에 저장
+						if err := store.AddDocuments(ctx, batch); err != nil {
+							log.Printf("⚠️  [워커 %d] 청크 %d개 저장 실패: %v", workerID, len(batch), err)
+							failBatch(batch)
+						} else {
+							atomic.AddInt64(&successCount, int64(len(batch)))
+							atomic.AddInt64(&processedCount, int64(len(batch)))
+						}
+					}
 				}
 
-				doc.Vector = vector
-
-				// DB에 저장
-				if err := store.AddDocument(ctx, doc); err != nil {
-					log.Printf("⚠️  [워커 %d] 문서 %s 저장 실패: %v", workerID, doc.ID, err)
-					atomic.AddInt64(&errorCount, 1)
-					atomic.AddInt64(&processedCount, 1)
-					continue
+				if !ok {
+					return
 				}
-
-				atomic.AddInt64(&successCount, 1)
-				atomic.AddInt64(&processedCount, 1)
 			}
 		}(i)
 	}
 
 	// Notion Producer 고루틴 시작
 	var producerErr error
+	var fetchedPages []models.PageInfo
 	var producerWg sync.WaitGroup
 	producerWg.Add(1)
 	go func() {
 		defer producerWg.Done()
 		fmt.Println("🧠 Notion Producer 시작 - Gemini Consumer와 병렬 처리 중...")
-		producerErr = loader.FetchAllPagesStream(ctx, docChan)
+		fetchedPages, producerErr = loader.FetchPagesStream(ctx, targets, docChan)
 		if producerErr != nil {
 			log.Printf("⚠️  Notion Producer 오류: %v", producerErr)
 		}
@@ -239,6 +444,12 @@ func processDocumentsPipeline(
 	// Producer 완료 대기
 	producerWg.Wait()
 
+	// 짧아져서 건너뛴 청크 자리에 이전 내용이 남아 검색되지 않도록 삭제
+	staleChunks, err := store.DeleteDocuments(ctx, skippedIDs...)
+	if err != nil {
+		return fmt.Errorf("건너뛴 청크의 이전 내용 정리 실패: %w", err)
+	}
+
 	// 최종 통계 출력
 	finalProcessed := atomic.LoadInt64(&processedCount)
 	finalSuccess := atomic.LoadInt64(&successCount)
@@ -247,6 +458,53 @@ func processDocumentsPipeline(
 
 	fmt.Printf("\n📊 최종 결과: 처리됨 %d (성공: %d, 실패: %d, 건너뜀: %d)\n",
 		finalProcessed, finalSuccess, finalErrors, finalSkipped)
+	if staleChunks > 0 {
+		fmt.Printf("🧹 건너뛴 청크 자리에 남아있던 이전 청크 %d개 삭제\n", staleChunks)
+	}
+	fmt.Printf("✂️  청크 크기 조정: 목표 크기를 넘어 나눈 블록 %d개, 입력 한도로 자른 청크 %d개\n",
+		loader.ChunkSplits(), atomic.LoadInt64(&truncatedCount))
+	fmt.Printf("🗃️  임베딩 캐시: 적중 %d, 미스 %d (총 %d개 항목)\n",
+		cache.Hits()-startHits, cache.Misses()-startMisses, cache.Len())
+	fmt.Printf("📄 페이지: 새 페이지 %d, 변경 %d, 변경 없음 %d",
+		newPages, updatedPages, unchangedPages)
+	if incremental {
+		fmt.Printf(" (변경 없는 페이지는 건너뜀)")
+	}
+	fmt.Println()
+
+	// 모든 청크가 저장된 페이지만 동기화 상태 기록
+	// 이전보다 청크 수가 줄어든 페이지는 남은 청크를 정리하기 위해 이전 청크 수를 함께 보관
+	synced := make([]models.PageInfo, 0, len(fetchedPages))
+	previousChunks := make(map[string]int)
+	for _, page := range fetchedPages {
+		if failedPages[page.ID] {
+			continue
+		}
+		if stored, ok := store.GetPage(page.ID); ok && stored.ChunkCount > page.ChunkCount {
+			previousChunks[page.ID] = stored.ChunkCount
+		}
+		synced = append(synced, page)
+	}
+	if err := store.PutPages(synced); err != nil {
+		return fmt.Errorf("페이지 동기화 상태 저장 실패: %w", err)
+	}
+
+	// 새로 임베딩한 벡터를 캐시에 저장
+	if err := cache.Save(); err != nil {
+		return err
+	}
+
+	// 새로 저장한 청크의 키워드 색인을 저장
+	if err := store.Flush(); err != nil {
+		return err
+	}
+
+	// Notion에서 사라진 페이지와 줄어든 페이지의 남은 청크 정리
+	if producerErr == nil {
+		if err := reconcileStore(ctx, store, livePages, synced, previousChunks); err != nil {
+			return err
+		}
+	}
 
 	if producerErr != nil {
 		return producerErr
@@ -255,14 +513,222 @@ func processDocumentsPipeline(
 	return nil
 }
 
-// showDocumentList 저장된 문서 목록을 보여줍니다
-func showDocumentList(ctx context.Context, store *db.Store, totalCount int) {
-	fmt.Printf("📚 저장된 문서 총 개수: %d개\n\n", totalCount)
-	fmt.Println("⚠️  참고: chromem-go의 API 제한으로 인해 모든 문서 목록을 직접 조회할 수 없습니다.")
-	fmt.Println("   대신 --search 옵션을 사용하여 특정 키워드로 검색할 수 있습니다.")
-	fmt.Println("\n사용 예:")
-	fmt.Println("  go run . --search \"스마트 리포트\"")
-	fmt.Println("  go run . --show <문서ID>")
+// ensureKeywordIndex 키워드 색인 파일이 없는 기존 DB거나 동기화 중단 등으로 색인된 청크 수가 다르면 저장된 청크로 다시 생성합니다
+// 페이지 인덱스가 없어 다시 만들 수 없으면 경고만 표시하고 다음 실행 때 다시 시도합니다
+func ensureKeywordIndex(ctx context.Context, store *db.Store) error {
+	if !store.KeywordIndexStale() {
+		return nil
+	}
+
+	fmt.Println("🔤 키워드 색인 생성 중...")
+	count, err := store.RebuildKeywordIndex(ctx)
+	if errors.Is(err, db.ErrNoIndexedChunk) {
+		fmt.Println("⚠️  페이지 인덱스에서 저장된 청크를 찾지 못해 키워드 색인을 만들지 않았습니다 (동기화 후 다시 시작하면 생성)")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ 키워드 색인 생성 완료: %d개 청크\n", count)
+	return nil
+}
+
+// batchFlushInterval 배치가 다 차지 않아도 모인 청크를 처리하기까지 기다리는 최대 시간
+// Notion Producer가 느릴 때 워커가 배치를 채우느라 오래 대기하지 않도록 합니다
+const batchFlushInterval = 2 * time.Second
+
+// collectBatch docChan에서 최대 size개의 청크를 모읍니다
+// 콘텐츠가 너무 짧은 청크는 건너뛰고 markSkipped로 알리며, 채널이 닫히면 ok=false를 반환합니다
+func collectBatch(docChan <-chan *models.Document, size int, markSkipped func(*models.Document)) ([]*models.Document, bool) {
+	var batch []*models.Document
+	var flush <-chan time.Time
+
+	for len(batch) < size {
+		select {
+		case doc, ok := <-docChan:
+			if !ok {
+				return batch, false
+			}
+
+			// 콘텐츠 길이 확인
+			if len([]rune(doc.Content)) < 50 {
+				markSkipped(doc)
+				continue
+			}
+
+			batch = append(batch, doc)
+			if flush == nil {
+				// 첫 청크를 받은 시점부터 대기 시간 측정
+				flush = time.After(batchFlushInterval)
+			}
+		case <-flush:
+			return batch, true
+		}
+	}
+
+	return batch, true
+}
+
+// embeddingText 청크를 임베딩할 텍스트를 구성합니다 (제목을 본문 앞에 추가)
+func embeddingText(doc *models.Document) string {
+	if doc.Title == "" {
+		return doc.Content
+	}
+	return doc.Title + "\n\n" + doc.Content
+}
+
+// reconcileStore Notion의 현재 페이지 목록과 저장된 상태를 비교하여 필요 없는 청크를 삭제합니다
+// 삭제·보관된 페이지는 모든 청크를, 청크 수가 줄어든 페이지는 뒤쪽의 남은 청크를 삭제합니다
+func reconcileStore(
+	ctx context.Context,
+	store *db.Store,
+	livePages map[string]bool,
+	synced []models.PageInfo,
+	previousChunks map[string]int,
+) error {
+	var (
+		removedPages  int
+		removedChunks int
+		orphanChunks  int
+	)
+
+	// 삭제되었거나 보관된 페이지
+	for _, pageID := range store.PageIDs() {
+		if livePages[pageID] {
+			continue
+		}
+		removed, err := store.DeleteByPage(ctx, pageID)
+		if err != nil {
+			return fmt.Errorf("삭제된 페이지 정리 실패: %w", err)
+		}
+		removedPages++
+		removedChunks += removed
+	}
+
+	// 청크 수가 줄어든 페이지의 남은 청크
+	for _, page := range synced {
+		previous, ok := previousChunks[page.ID]
+		if !ok {
+			continue
+		}
+		var ids []string
+		for idx := page.ChunkCount; idx < previous; idx++ {
+			ids = append(ids, models.ChunkID(page.ID, idx))
+		}
+		removed, err := store.DeleteDocuments(ctx, ids...)
+		if err != nil {
+			return fmt.Errorf("페이지 %s의 남은 청크 정리 실패: %w", page.ID, err)
+		}
+		orphanChunks += removed
+	}
+
+	// 삭제한 청크를 키워드 색인에도 반영
+	if err := store.Flush(); err != nil {
+		return err
+	}
+
+	if removedPages > 0 || orphanChunks > 0 {
+		fmt.Printf("🧹 정리 결과: 삭제된 페이지 %d개 (청크 %d개), 남은 청크 %d개 제거\n",
+			removedPages, removedChunks, orphanChunks)
+	} else {
+		fmt.Println("🧹 정리할 페이지나 청크가 없습니다.")
+	}
+
+	return nil
+}
+
+// pruneEmbeddingCache DB에 저장된 청크가 참조하지 않는 임베딩 캐시 항목을 삭제합니다
+// 현재 임베딩 모델로 저장된 청크의 임베딩 텍스트(RETRIEVAL_DOCUMENT)만 참조된 것으로 취급합니다
+func pruneEmbeddingCache(ctx context.Context, store *db.Store, embedCfg embedding.Config, cache *embedding.Cache) error {
+	embedder, err := embedding.New(ctx, embedCfg)
+	if err != nil {
+		return fmt.Errorf("임베딩 생성기 초기화 실패: %w", err)
+	}
+	modelID := embedder.ModelID()
+	embedder.Close()
+
+	// 파이프라인과 같은 방식으로 입력 한도를 넘는 텍스트를 잘라서 키를 계산
+	tokenizer, err := embedding.NewTokenizer(embedCfg.Tokenizer)
+	if err != nil {
+		return err
+	}
+	maxTokens := embedCfg.MaxInputTokens()
+
+	// 페이지별 청크 수로 청크 ID를 만들어 저장된 청크를 조회
+	keep := make(map[string]bool)
+	for _, pageID := range store.PageIDs() {
+		page, ok := store.GetPage(pageID)
+		if !ok {
+			continue
+		}
+		for idx := 0; idx < page.ChunkCount; idx++ {
+			doc, err := store.GetByID(ctx, models.ChunkID(pageID, idx))
+			if err != nil {
+				// 콘텐츠가 짧아 저장되지 않은 청크
+				continue
+			}
+			text, _ := embedding.TruncateTokens(tokenizer, embeddingText(doc), maxTokens)
+			keep[embedding.CacheKey(modelID, embedding.TaskRetrievalDocument, text)] = true
+		}
+	}
+
+	before := cache.Len()
+	removed := cache.Prune(keep)
+	if err := cache.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("🧹 임베딩 캐시 정리: %d개 중 %d개 삭제, %d개 유지\n", before, removed, cache.Len())
+	return nil
+}
+
+// showDocumentList 동기화된 페이지 목록을 제목 필터·정렬·페이지 단위로 보여줍니다
+func showDocumentList(ctx context.Context, store *db.Store, totalCount int, title, sortBy string, page, pageSize int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	opts := db.ListOptions{
+		Title:  title,
+		Sort:   strings.TrimPrefix(sortBy, "-"),
+		Desc:   strings.HasPrefix(sortBy, "-"),
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	}
+	pages, total, err := store.ListAll(ctx, opts)
+	if err != nil {
+		log.Fatalf("페이지 목록 조회 실패: %v", err)
+	}
+
+	fmt.Printf("📚 저장된 문서(청크) 총 개수: %d개\n", totalCount)
+	if title != "" {
+		fmt.Printf("🔎 제목 필터: \"%s\"\n", title)
+	}
+	totalPages := (total + pageSize - 1) / pageSize
+	fmt.Printf("📄 페이지 %d개 (%d/%d쪽, 정렬: %s)\n\n", total, page, max(totalPages, 1), sortBy)
+
+	if total == 0 {
+		fmt.Println("표시할 페이지가 없습니다. (--reload 또는 --sync로 동기화한 페이지만 표시됩니다)")
+		return
+	}
+
+	for i, p := range pages {
+		fmt.Printf("%3d. %s\n", opts.Offset+i+1, p.Title)
+		fmt.Printf("     ID: %s | 청크 %d개 | 수정일: %s | 생성일: %s\n", p.ID, p.ChunkCount, p.LastEdit, p.Created)
+		fmt.Printf("     URL: %s\n", p.URL)
+	}
+
+	if page < totalPages {
+		fmt.Printf("\n다음 쪽: --list --page %d", page+1)
+		if title != "" {
+			fmt.Printf(" --title \"%s\"", title)
+		}
+		fmt.Println()
+	}
+	fmt.Println("\n청크 내용 보기: go run . --show <페이지ID>-chunk-0")
 }
 
 // showDocumentByID 특정 문서 ID로 내용을 보여줍니다
@@ -296,38 +762,62 @@ func showDocumentByID(ctx context.Context, store *db.Store, docID string) {
 	fmt.Println("---")
 }
 
-// searchDocuments 텍스트로 문서를 검색합니다
-func searchDocuments(ctx context.Context, store *db.Store, geminiAPIKey string, query string) {
-	fmt.Printf("🔍 검색어: \"%s\"\n\n", query)
+// searchDocuments 텍스트로 문서를 검색합니다 (설정한 검색 방식 사용, 답변은 생성하지 않음)
+func searchDocuments(ctx context.Context, store *db.Store, geminiAPIKey string, embedCfg embedding.Config, cache *embedding.Cache, searchCfg rag.Config, filter db.Filter, query string) {
+	searchCfg = searchCfg.WithDefaults()
+	fmt.Printf("🔍 검색어: \"%s\" (검색 방식: %s)\n", query, searchCfg.Mode)
+	if searchCfg.HyDE && searchCfg.Mode != rag.ModeKeyword {
+		fmt.Printf("📝 HyDE: 가상 문서 임베딩으로 검색 (질문 벡터 비율 %.2f)\n", searchCfg.HyDEQueryWeight)
+	}
+	if searchCfg.Rerank.Enabled() {
+		fmt.Printf("🏅 재순위: %s (후보 %d개)\n", searchCfg.Rerank.Provider, searchCfg.Rerank.EffectiveCandidates(searchCfg.TopK))
+	}
+	if searchCfg.MMRLambda > 0 || searchCfg.MaxPerPage > 0 {
+		fmt.Printf("🧩 다양화: MMR lambda %.2f, 페이지당 최대 %d개 (0은 제한 없음)\n", searchCfg.MMRLambda, searchCfg.MaxPerPage)
+	}
+	if len(filter) > 0 {
+		fmt.Printf("🔎 검색 범위: %s\n", filter)
+	}
+	fmt.Println()
 
 	// 임베딩 생성기 초기화
-	embedder, err := embedding.NewEmbedder(ctx, geminiAPIKey)
+	embedder, err := embedding.New(ctx, embedCfg)
 	if err != nil {
 		log.Fatalf("임베딩 생성기 초기화 실패: %v", err)
 	}
+	embedder = embedding.WithCache(embedder, cache)
 	defer embedder.Close()
 
-	// 검색 쿼리를 임베딩으로 변환
-	queryVector, err := embedder.EmbedText(query, "RETRIEVAL_QUERY")
+	searcher, err := rag.NewSearcher(ctx, geminiAPIKey, embedder, store, searchCfg)
 	if err != nil {
-		log.Fatalf("검색 쿼리 임베딩 실패: %v", err)
+		log.Fatalf("RAG 검색기 초기화 실패: %v", err)
 	}
+	defer searcher.Close()
 
 	// 검색 실행
-	documents, err := store.Search(ctx, queryVector, 10) // Top 10
+	results, err := searcher.Retrieve(query, filter)
 	if err != nil {
 		log.Fatalf("검색 실패: %v", err)
 	}
 
-	if len(documents) == 0 {
-		fmt.Println("검색 결과가 없습니다.")
+	if len(results) == 0 {
+		if searchCfg.Mode == rag.ModeKeyword {
+			fmt.Println("검색어의 단어가 포함된 검색 결과가 없습니다.")
+		} else {
+			fmt.Printf("유사도 %.2f 이상인 검색 결과가 없습니다.\n", searchCfg.MinSimilarity)
+		}
 		return
 	}
 
-	fmt.Printf("📊 검색 결과: %d개 문서\n\n", len(documents))
-	for i, doc := range documents {
+	fmt.Printf("📊 검색 결과: %d개 문서\n\n", len(results))
+	for i, result := range results {
+		doc := result.Document
 		fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-		fmt.Printf("결과 %d:\n", i+1)
+		if result.Score != result.Similarity {
+			fmt.Printf("결과 %d (유사도: %.3f, 점수: %.4f):\n", i+1, result.Similarity, result.Score)
+		} else {
+			fmt.Printf("결과 %d (유사도: %.3f):\n", i+1, result.Similarity)
+		}
 		if doc.Title != "" {
 			fmt.Printf("제목: %s\n", doc.Title)
 		}
@@ -1,11 +1,52 @@
 package models
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Document Notion에서 가져온 문서를 나타내는 구조체
 type Document struct {
-	ID           string            // 문서 ID (청크의 경우 고유 ID)
-	Title        string            // 페이지 제목
-	Content      string            // 본문 텍스트
-	Vector       []float32         // 임베딩 벡터
-	Meta         map[string]string // 메타데이터 (URL, 작성일 등)
-	ParentPageID string            // 원본 페이지 ID (청킹된 경우)
+	ID           string            `json:"id"`             // 문서 ID (청크의 경우 고유 ID)
+	Title        string            `json:"title"`          // 페이지 제목
+	Content      string            `json:"content"`        // 본문 텍스트
+	Vector       []float32         `json:"-"`              // 임베딩 벡터
+	Meta         map[string]string `json:"meta,omitempty"` // 메타데이터 (URL, 작성일 등)
+	ParentPageID string            `json:"parent_page_id"` // 원본 페이지 ID (청킹된 경우)
+}
+
+// SearchResult 검색 결과 (문서와 질의와의 유사도, 순위 점수)
+type SearchResult struct {
+	Document   *Document `json:"document"`   // 검색된 문서 (청크)
+	Similarity float32   `json:"similarity"` // 코사인 유사도 (0~1 범위, 높을수록 유사)
+	Score      float32   `json:"score"`      // 순위를 정한 점수 (벡터 검색은 유사도, 키워드 검색은 BM25, 하이브리드는 RRF 점수)
+}
+
+// PageInfo 동기화된 Notion 페이지의 요약 정보
+type PageInfo struct {
+	ID         string `json:"id"`          // 페이지 ID
+	Title      string `json:"title"`       // 페이지 제목
+	URL        string `json:"url"`         // Notion URL
+	Created    string `json:"created"`     // 생성일 (RFC3339)
+	LastEdit   string `json:"last_edit"`   // 마지막 수정일 (RFC3339)
+	ChunkCount int    `json:"chunk_count"` // 저장된 청크 개수
+}
+
+// ChunkID 페이지 ID와 청크 순번으로 청크 문서 ID를 생성합니다
+func ChunkID(pageID string, index int) string {
+	return fmt.Sprintf("%s-chunk-%d", pageID, index)
+}
+
+// ParseChunkID 청크 문서 ID를 페이지 ID와 청크 순번으로 나눕니다 (ChunkID 형식이 아니면 ok=false)
+func ParseChunkID(id string) (pageID string, index int, ok bool) {
+	idx := strings.LastIndex(id, "-chunk-")
+	if idx < 0 {
+		return "", 0, false
+	}
+	index, err := strconv.Atoi(id[idx+len("-chunk-"):])
+	if err != nil || index < 0 {
+		return "", 0, false
+	}
+	return id[:idx], index, true
 }
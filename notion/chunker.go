@@ -0,0 +1,465 @@
+package notion
+
+import (
+	"strings"
+	"unicode"
+
+	"goc-notion-rag/embedding"
+)
+
+// 청킹 기본값 (토큰 단위)
+const (
+	DefaultChunkSize    = 500
+	DefaultChunkOverlap = 50
+)
+
+// chunkReserveTokens 임베딩 입력 한도에서 제목과 제목 경로를 위해 남겨두는 토큰 수
+const chunkReserveTokens = 64
+
+// ChunkConfig 청킹 설정 (config.json의 "chunking" 항목, 단위는 토큰)
+type ChunkConfig struct {
+	Size    int `json:"size"`    // 청크 목표 크기 (0이면 DefaultChunkSize)
+	Overlap int `json:"overlap"` // 이전 청크와 겹치게 할 크기 (0이면 DefaultChunkOverlap, 음수면 겹치지 않음)
+}
+
+// withDefaults 비어있는 값을 기본값으로 채운 설정을 반환합니다
+func (c ChunkConfig) withDefaults() ChunkConfig {
+	if c.Size <= 0 {
+		c.Size = DefaultChunkSize
+	}
+	switch {
+	case c.Overlap == 0:
+		c.Overlap = DefaultChunkOverlap
+	case c.Overlap < 0:
+		c.Overlap = 0
+	}
+	// 겹침이 청크 크기의 절반을 넘으면 청크가 거의 전진하지 않으므로 제한
+	if c.Overlap > c.Size/2 {
+		c.Overlap = c.Size / 2
+	}
+	return c
+}
+
+// segmentKind 본문을 나눈 조각의 종류
+type segmentKind int
+
+const (
+	segmentText    segmentKind = iota // 문단, 목록, 표 행 등 일반 텍스트
+	segmentHeading                    // 제목 (# ~ ######)
+	segmentCode                       // ``` 코드 블록
+)
+
+// segment extractTextFromBlock이 만든 Markdown 형태의 본문을 구조 단위로 나눈 조각
+type segment struct {
+	kind  segmentKind
+	level int    // 제목 수준 (segmentHeading만 사용)
+	text  string // 원문 텍스트
+}
+
+// chunk 청크 하나를 구성 중인 상태
+type chunk struct {
+	path       []string  // 청크 시작 시점의 제목 경로
+	segments   []segment // 청크에 포함된 조각 (앞쪽 overlapped개는 이전 청크에서 이어받은 조각)
+	overlapped int       // 이전 청크에서 이어받은 조각 수
+	size       int       // 본문 크기
+}
+
+// hasNew 이전 청크에서 이어받은 조각 외에 새 조각이 있는지 확인합니다
+func (ch chunk) hasNew() bool {
+	return len(ch.segments) > ch.overlapped
+}
+
+// Chunker 제목·목록·코드 블록·표 행 경계를 지키면서 본문을 청크로 나눕니다
+// 크기는 토크나이저로 센 임베딩 모델의 토큰 수로 측정합니다
+type Chunker struct {
+	size      int
+	overlap   int
+	tokenizer embedding.Tokenizer
+	splits    int // 목표 크기를 넘어 나눈 블록 수
+}
+
+// NewChunker 새로운 청커를 생성합니다
+// maxTokens가 0보다 크면 청크 크기가 임베딩 모델의 최대 입력을 넘지 않도록 제한합니다
+func NewChunker(cfg ChunkConfig, tokenizer embedding.Tokenizer, maxTokens int) *Chunker {
+	cfg = cfg.withDefaults()
+	if maxTokens > 0 {
+		limit := maxTokens - chunkReserveTokens
+		if limit < maxTokens/2 {
+			limit = maxTokens / 2
+		}
+		if cfg.Size > limit {
+			cfg.Size = limit
+			cfg = cfg.withDefaults() // 겹침 크기를 다시 제한
+		}
+	}
+	return &Chunker{size: cfg.Size, overlap: cfg.Overlap, tokenizer: tokenizer}
+}
+
+// Size 청크 목표 크기(토큰)를 반환합니다
+func (c *Chunker) Size() int {
+	return c.size
+}
+
+// Splits 목표 크기를 넘어 여러 조각으로 나눈 블록 수를 반환합니다
+func (c *Chunker) Splits() int {
+	return c.splits
+}
+
+// count 텍스트의 토큰 수를 반환합니다
+func (c *Chunker) count(text string) int {
+	return c.tokenizer.CountTokens(text)
+}
+
+// Split 본문을 청크로 나눕니다
+// 조각을 목표 크기까지 묶고, 다음 청크는 이전 청크의 마지막 조각들을 겹침 크기만큼 이어받습니다
+// 각 청크 앞에는 해당 위치의 제목 경로(예: "[설치 > 빌드]")를 붙입니다
+func (c *Chunker) Split(text string) []string {
+	var (
+		chunks   []string
+		current  chunk
+		headings []segment // 현재 위치의 제목 스택 (상위 수준부터)
+		path     []string
+	)
+
+	add := func(seg segment) {
+		n := c.count(seg.text)
+		if current.hasNew() && current.size+n > c.size {
+			// 청크 끝의 제목은 본문과 떨어지지 않도록 다음 청크로 넘김
+			var carry []segment
+			last := len(current.segments) - 1
+			if current.segments[last].kind == segmentHeading && last > current.overlapped {
+				carry = current.segments[last:]
+				current.segments = current.segments[:last]
+			}
+
+			// 현재 청크를 내보내고 마지막 조각들을 다음 청크로 이어받음
+			chunks = append(chunks, current.render())
+			tail := c.overlapTail(current.segments)
+			if c.segmentsSize(tail)+c.segmentsSize(carry)+n > c.size {
+				// 이어받은 조각과 합치면 크기를 넘는 경우 겹침을 버림
+				tail = nil
+			}
+			current = chunk{
+				path:       path,
+				segments:   append(tail, carry...),
+				overlapped: len(tail),
+			}
+			current.size = c.segmentsSize(current.segments)
+		}
+		if len(current.segments) == 0 {
+			current.path = path
+		}
+		current.segments = append(current.segments, seg)
+		current.size += n
+	}
+
+	for _, seg := range parseSegments(text) {
+		if seg.kind == segmentHeading {
+			// 제목 경로 갱신 (새 제목과 같거나 하위 수준의 제목을 모두 꺼낸 뒤 추가)
+			for len(headings) > 0 && headings[len(headings)-1].level >= seg.level {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, seg)
+
+			// 청크가 이전 경로를 참조하고 있으므로 항상 새 슬라이스를 만듦
+			path = make([]string, len(headings))
+			for i, heading := range headings {
+				path[i] = headingTitle(heading.text)
+			}
+		}
+
+		// 남은 공간에 들어가지 않는 조각은 다음 청크로 넘기되,
+		// 어차피 나눠야 하는 큰 조각이거나 현재 청크가 너무 작으면 남은 공간부터 채움
+		n := c.count(seg.text)
+		remaining := c.size - current.size
+		small := current.hasNew() && current.size < c.size/4
+		if n <= remaining || (n <= c.size && (!small || seg.kind != segmentText)) {
+			add(seg)
+			continue
+		}
+
+		// 문장(코드는 줄) 단위로 나누어 추가
+		if n > c.size {
+			c.splits++
+		}
+		for _, piece := range c.splitSegment(seg, remaining) {
+			add(piece)
+		}
+	}
+
+	if current.hasNew() {
+		chunks = append(chunks, current.render())
+	}
+
+	return chunks
+}
+
+// overlapTail 다음 청크로 이어받을 마지막 조각들을 반환합니다 (합이 겹침 크기 이하인 온전한 조각만)
+// 코드 블록은 중간에서 자를 수 없으므로 통째로 들어갈 때만 포함합니다
+func (c *Chunker) overlapTail(segments []segment) []segment {
+	if c.overlap <= 0 {
+		return nil
+	}
+
+	total := 0
+	start := len(segments)
+	for i := len(segments) - 1; i >= 0; i-- {
+		n := c.count(segments[i].text)
+		if total+n > c.overlap {
+			break
+		}
+		total += n
+		start = i
+	}
+
+	if start == len(segments) {
+		// 마지막 조각이 겹침 크기보다 크면 끝부분의 문장만 이어받음
+		last := segments[len(segments)-1]
+		if last.kind != segmentText {
+			return nil
+		}
+		tail := c.tailSentences(last.text, c.overlap)
+		if tail == "" {
+			return nil
+		}
+		return []segment{{kind: segmentText, text: tail}}
+	}
+
+	return append([]segment(nil), segments[start:]...)
+}
+
+// splitSegment 조각을 목표 크기 이하로 나눕니다 (일반 텍스트의 첫 묶음은 first 이하로 채움)
+func (c *Chunker) splitSegment(seg segment, first int) []segment {
+	switch seg.kind {
+	case segmentCode:
+		return c.splitCode(seg)
+	default:
+		var pieces []segment
+		for _, part := range c.packPieces(splitSentences(seg.text), first, c.size, " ") {
+			pieces = append(pieces, segment{kind: segmentText, text: part})
+		}
+		return pieces
+	}
+}
+
+// splitCode 코드 블록을 줄 단위로 나누고 각 조각을 다시 ``` 로 감쌉니다
+func (c *Chunker) splitCode(seg segment) []segment {
+	lines := strings.Split(seg.text, "\n")
+	openFence, closeFence := lines[0], "```"
+	body := lines[1:]
+	if len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "```" {
+		body = body[:len(body)-1]
+	}
+
+	// 여는/닫는 fence 크기를 빼고 본문을 나눔
+	limit := c.size - c.count(openFence) - c.count(closeFence)
+	if limit < 1 {
+		limit = 1
+	}
+
+	var pieces []segment
+	for _, part := range c.packPieces(body, limit, limit, "\n") {
+		pieces = append(pieces, segment{kind: segmentCode, text: openFence + "\n" + part + "\n" + closeFence})
+	}
+	return pieces
+}
+
+// render 청크를 제목 경로와 본문을 합친 텍스트로 변환합니다
+func (ch chunk) render() string {
+	parts := make([]string, 0, len(ch.segments))
+	for _, seg := range ch.segments {
+		parts = append(parts, seg.text)
+	}
+	body := strings.Join(parts, "\n\n")
+
+	var path []string
+	for _, title := range ch.path {
+		if title != "" {
+			path = append(path, title)
+		}
+	}
+	if len(path) == 0 {
+		return body
+	}
+	return "[" + strings.Join(path, " > ") + "]\n\n" + body
+}
+
+// parseSegments 본문을 제목, 코드 블록, 일반 텍스트 조각으로 나눕니다
+// 일반 텍스트는 빈 줄을 경계로 나뉩니다 (블록마다 빈 줄로 구분되어 있음)
+func parseSegments(text string) []segment {
+	var (
+		segments []segment
+		lines    []string
+	)
+
+	flushText := func() {
+		if len(lines) == 0 {
+			return
+		}
+		segments = append(segments, segment{kind: segmentText, text: strings.Join(lines, "\n")})
+		lines = nil
+	}
+
+	all := strings.Split(text, "\n")
+	for i := 0; i < len(all); i++ {
+		line := all[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			// 닫는 fence까지 하나의 코드 블록으로 묶음
+			flushText()
+			code := []string{line}
+			for i+1 < len(all) {
+				i++
+				code = append(code, all[i])
+				if strings.TrimSpace(all[i]) == "```" {
+					break
+				}
+			}
+			segments = append(segments, segment{kind: segmentCode, text: strings.Join(code, "\n")})
+		case trimmed == "":
+			flushText()
+		case headingLevel(trimmed) > 0:
+			flushText()
+			segments = append(segments, segment{kind: segmentHeading, level: headingLevel(trimmed), text: trimmed})
+		default:
+			lines = append(lines, line)
+		}
+	}
+	flushText()
+
+	return segments
+}
+
+// headingLevel Markdown 제목 줄의 수준을 반환합니다 (제목이 아니면 0)
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// headingTitle 제목 줄에서 # 표시를 제거한 제목을 반환합니다
+func headingTitle(line string) string {
+	return strings.TrimSpace(strings.TrimLeft(line, "#"))
+}
+
+// splitSentences 텍스트를 문장 단위로 나눕니다 (줄바꿈과 . ? ! 뒤의 공백을 경계로 사용)
+func splitSentences(text string) []string {
+	var (
+		sentences []string
+		current   []rune
+	)
+
+	runes := []rune(text)
+	for i, r := range runes {
+		current = append(current, r)
+
+		end := r == '\n'
+		if (r == '.' || r == '?' || r == '!' || r == '。') && i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+			end = true
+		}
+		if end {
+			if s := strings.TrimSpace(string(current)); s != "" {
+				sentences = append(sentences, s)
+			}
+			current = nil
+		}
+	}
+	if s := strings.TrimSpace(string(current)); s != "" {
+		sentences = append(sentences, s)
+	}
+
+	return sentences
+}
+
+// packPieces 조각들을 sep로 이어 붙여 limit 이하의 묶음으로 만듭니다
+// 첫 묶음은 first 이하로 채우되, 첫 조각이 first에 들어가지 않으면 limit을 적용합니다
+// 조각 하나가 limit보다 크면 limit 토큰 단위로 잘라서 넣습니다
+func (c *Chunker) packPieces(pieces []string, first, limit int, sep string) []string {
+	var (
+		packed  []string
+		current []string
+		size    int
+	)
+
+	if first <= 0 || first > limit {
+		first = limit
+	}
+	capacity := first
+
+	flush := func() {
+		if len(current) > 0 {
+			packed = append(packed, strings.Join(current, sep))
+			current, size = nil, 0
+		}
+		capacity = limit
+	}
+
+	for _, piece := range pieces {
+		n := c.count(piece)
+		if len(packed) == 0 && len(current) == 0 && n > capacity {
+			// 첫 조각이 남은 공간에 들어가지 않음
+			capacity = limit
+		}
+		if n > limit {
+			flush()
+			for rest := piece; rest != ""; {
+				head := embedding.PrefixTokens(c.tokenizer, rest, limit)
+				if head == "" {
+					// 글자 하나가 limit을 넘는 경우에도 진행되도록 한 글자씩 자름
+					head = string([]rune(rest)[:1])
+				}
+				packed = append(packed, head)
+				rest = rest[len(head):]
+			}
+			continue
+		}
+
+		extra := n
+		if len(current) > 0 {
+			extra += c.count(sep)
+		}
+		if size+extra > capacity {
+			flush()
+			extra = n
+		}
+		current = append(current, piece)
+		size += extra
+	}
+	flush()
+
+	return packed
+}
+
+// tailSentences 텍스트 끝에서부터 limit 토큰 이하가 되도록 온전한 문장들을 반환합니다
+func (c *Chunker) tailSentences(text string, limit int) string {
+	sentences := splitSentences(text)
+
+	var tail []string
+	size := 0
+	for i := len(sentences) - 1; i >= 0; i-- {
+		n := c.count(sentences[i])
+		if size+n > limit {
+			break
+		}
+		tail = append([]string{sentences[i]}, tail...)
+		size += n
+	}
+
+	return strings.Join(tail, " ")
+}
+
+// segmentsSize 조각들의 전체 토큰 수를 반환합니다
+func (c *Chunker) segmentsSize(segments []segment) int {
+	size := 0
+	for _, seg := range segments {
+		size += c.count(seg.text)
+	}
+	return size
+}
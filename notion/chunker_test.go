@@ -0,0 +1,31 @@
+package notion
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"goc-notion-rag/embedding"
+)
+
+// TestChunkerHeadingPath 같거나 상위 수준의 제목이 나오면 그보다 깊은 제목이 경로에서 빠지는지 확인합니다
+func TestChunkerHeadingPath(t *testing.T) {
+	body := strings.Repeat("내용", 8)
+	sections := []string{"# A", "## B", "### C", "## D", "#### E", "# F", "### G", "## H"}
+	var parts []string
+	for _, heading := range sections {
+		parts = append(parts, heading, body)
+	}
+
+	// 제목과 본문 하나씩만 들어가는 크기로 나눠 각 청크의 경로를 확인
+	chunker := NewChunker(ChunkConfig{Size: 30, Overlap: -1}, embedding.RuneTokenizer{}, 0)
+	var got []string
+	for _, chunk := range chunker.Split(strings.Join(parts, "\n\n")) {
+		got = append(got, strings.SplitN(chunk, "\n", 2)[0])
+	}
+
+	want := []string{"[A]", "[A > B]", "[A > B > C]", "[A > D]", "[A > D > E]", "[F]", "[F > G]", "[F > H]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("제목 경로 = %q, 기대 %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package notion
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -12,22 +13,35 @@ import (
 )
 
 const (
-	chunkSize      = 1000 // 청킹 크기 (문자 단위)
 	rateLimitDelay = 350 * time.Millisecond
 )
 
 // Loader Notion API를 사용하여 문서를 로드하는 구조체
 type Loader struct {
-	client *notionapi.Client
+	client     *notionapi.Client
+	chunker    *Chunker
+	titleCache map[string]string // 데이터베이스·관계 페이지 제목 캐시 (ID → 제목)
+	parents    map[string]string // 페이지의 상위 페이지·데이터베이스 ID (하위 트리 필터용)
+	rawProps   *propertyRecorder // 검색 응답의 원본 속성 정보 (비어있는 숫자, 날짜의 시각 유무)
 }
 
 // NewLoader 새로운 Notion 로더를 생성합니다
-func NewLoader(apiKey string) *Loader {
+func NewLoader(apiKey string, chunker *Chunker) *Loader {
+	rawProps := newPropertyRecorder(http.DefaultTransport)
 	return &Loader{
-		client: notionapi.NewClient(notionapi.Token(apiKey)),
+		client:     notionapi.NewClient(notionapi.Token(apiKey), notionapi.WithHTTPClient(&http.Client{Transport: rawProps})),
+		chunker:    chunker,
+		titleCache: make(map[string]string),
+		parents:    make(map[string]string),
+		rawProps:   rawProps,
 	}
 }
 
+// ChunkSplits 청크 목표 크기를 넘어 여러 조각으로 나눈 블록 수를 반환합니다
+func (l *Loader) ChunkSplits() int {
+	return l.chunker.Splits()
+}
+
 // FetchAllPages 모든 Notion 페이지를 가져와서 Document 슬라이스로 변환합니다
 func (l *Loader) FetchAllPages(ctx context.Context) ([]*models.Document, error) {
 	var allDocuments []*models.Document
@@ -44,23 +58,14 @@ func (l *Loader) FetchAllPages(ctx context.Context) ([]*models.Document, error)
 	for i, page := range pages {
 		fmt.Printf("처리 중: %d/%d - %s\n", i+1, len(pages), getPageTitle(page))
 
-		// 페이지 블록 가져오기 (PageID를 BlockID로 변환)
+		// 페이지 본문과 메타데이터 가져오기
 		pageID := string(page.ID)
-		content, err := l.fetchPageContent(ctx, notionapi.BlockID(pageID))
+		content, meta, err := l.pageContent(ctx, page)
 		if err != nil {
 			fmt.Printf("⚠️  페이지 %s 처리 실패: %v\n", pageID, err)
 			continue
 		}
 
-		// 페이지 메타데이터 구성
-		meta := map[string]string{
-			"page_id":   pageID,
-			"title":     getPageTitle(page),
-			"url":       getPageURL(page),
-			"created":   page.CreatedTime.Format(time.RFC3339),
-			"last_edit": page.LastEditedTime.Format(time.RFC3339),
-		}
-
 		// 콘텐츠 길이 확인 및 디버깅
 		contentLen := len([]rune(content))
 		fmt.Printf("  콘텐츠 길이: %d자\n", contentLen)
@@ -75,13 +80,13 @@ func (l *Loader) FetchAllPages(ctx context.Context) ([]*models.Document, error)
 		}
 
 		// 청킹 처리
-		chunks := chunkText(content, chunkSize)
+		chunks := l.chunker.Split(content)
 		fmt.Printf("  청크 개수: %d개\n", len(chunks))
 
 		for idx, chunk := range chunks {
 			chunkLen := len([]rune(chunk))
 			doc := &models.Document{
-				ID:           fmt.Sprintf("%s-chunk-%d", pageID, idx),
+				ID:           models.ChunkID(pageID, idx),
 				Title:        getPageTitle(page),
 				Content:      chunk,
 				ParentPageID: pageID,
@@ -101,44 +106,54 @@ func (l *Loader) FetchAllPages(ctx context.Context) ([]*models.Document, error)
 // FetchAllPagesStream 모든 Notion 페이지를 가져와서 채널을 통해 실시간으로 전송합니다
 // Producer 패턴으로 사용되며, 페이지를 가져오는 즉시 청킹하여 채널에 전송합니다
 func (l *Loader) FetchAllPagesStream(ctx context.Context, docChan chan<- *models.Document) error {
-	defer close(docChan)
-
 	// Search API로 모든 페이지 조회
 	pages, err := l.searchAllPages(ctx)
 	if err != nil {
+		close(docChan)
 		return fmt.Errorf("페이지 검색 실패: %w", err)
 	}
 
-	fmt.Printf("📄 총 %d개의 페이지를 찾았습니다.\n", len(pages))
+	_, err = l.FetchPagesStream(ctx, pages, docChan)
+	return err
+}
+
+// ListPages Search API로 워크스페이스의 모든 페이지를 조회합니다 (블록은 가져오지 않음)
+func (l *Loader) ListPages(ctx context.Context) ([]notionapi.Page, error) {
+	pages, err := l.searchAllPages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("페이지 검색 실패: %w", err)
+	}
+	return pages, nil
+}
+
+// FetchPagesStream 주어진 페이지들의 본문을 가져와서 청킹한 뒤 채널로 전송합니다
+// 전송이 끝나면 채널을 닫고, 끝까지 처리된 페이지(콘텐츠가 짧아 건너뛴 페이지 포함)의 정보를 반환합니다
+func (l *Loader) FetchPagesStream(ctx context.Context, pages []notionapi.Page, docChan chan<- *models.Document) ([]models.PageInfo, error) {
+	defer close(docChan)
+
+	fmt.Printf("📄 총 %d개의 페이지를 가져옵니다.\n", len(pages))
+
+	var fetched []models.PageInfo
 
 	// 각 페이지 처리
 	for i, page := range pages {
 		// 컨텍스트 취소 확인
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return fetched, ctx.Err()
 		default:
 		}
 
 		fmt.Printf("처리 중: %d/%d - %s\n", i+1, len(pages), getPageTitle(page))
 
-		// 페이지 블록 가져오기 (PageID를 BlockID로 변환)
+		// 페이지 본문과 메타데이터 가져오기
 		pageID := string(page.ID)
-		content, err := l.fetchPageContent(ctx, notionapi.BlockID(pageID))
+		content, meta, err := l.pageContent(ctx, page)
 		if err != nil {
 			fmt.Printf("⚠️  페이지 %s 처리 실패: %v\n", pageID, err)
 			continue
 		}
 
-		// 페이지 메타데이터 구성
-		meta := map[string]string{
-			"page_id":   pageID,
-			"title":     getPageTitle(page),
-			"url":       getPageURL(page),
-			"created":   page.CreatedTime.Format(time.RFC3339),
-			"last_edit": page.LastEditedTime.Format(time.RFC3339),
-		}
-
 		// 콘텐츠 길이 확인
 		contentLen := len([]rune(content))
 		fmt.Printf("  콘텐츠 길이: %d자\n", contentLen)
@@ -146,18 +161,19 @@ func (l *Loader) FetchAllPagesStream(ctx context.Context, docChan chan<- *models
 		// 빈 콘텐츠 또는 너무 짧은 콘텐츠는 건너뛰기
 		if contentLen < 50 {
 			fmt.Printf("  ⚠️  콘텐츠가 너무 짧아 건너뜁니다 (길이: %d자)\n", contentLen)
+			fetched = append(fetched, pageInfo(page, 0))
 			continue
 		}
 
 		// 청킹 처리
-		chunks := chunkText(content, chunkSize)
+		chunks := l.chunker.Split(content)
 		fmt.Printf("  청크 개수: %d개\n", len(chunks))
 
 		// 각 청크를 채널에 전송
 		for idx, chunk := range chunks {
 			chunkLen := len([]rune(chunk))
 			doc := &models.Document{
-				ID:           fmt.Sprintf("%s-chunk-%d", pageID, idx),
+				ID:           models.ChunkID(pageID, idx),
 				Title:        getPageTitle(page),
 				Content:      chunk,
 				ParentPageID: pageID,
@@ -167,17 +183,19 @@ func (l *Loader) FetchAllPagesStream(ctx context.Context, docChan chan<- *models
 			// 채널에 전송 (컨텍스트 취소 확인)
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
+				return fetched, ctx.Err()
 			case docChan <- doc:
 				fmt.Printf("    청크 %d: %d자 전송\n", idx, chunkLen)
 			}
 		}
 
+		fetched = append(fetched, pageInfo(page, len(chunks)))
+
 		// Rate limit 방지
 		time.Sleep(rateLimitDelay)
 	}
 
-	return nil
+	return fetched, nil
 }
 
 // searchAllPages Search API를 사용하여 모든 페이지를 검색합니다
@@ -208,6 +226,7 @@ func (l *Loader) searchAllPages(ctx context.Context) ([]notionapi.Page, error) {
 				// Page는 포인터 타입으로 Object 인터페이스를 구현
 				if pagePtr, ok := obj.(*notionapi.Page); ok {
 					allPages = append(allPages, *pagePtr)
+					l.recordParent(*pagePtr)
 				}
 			}
 		}
@@ -223,15 +242,79 @@ func (l *Loader) searchAllPages(ctx context.Context) ([]notionapi.Page, error) {
 	return allPages, nil
 }
 
+// recordParent 페이지의 상위 페이지 또는 데이터베이스 ID를 기록합니다
+func (l *Loader) recordParent(page notionapi.Page) {
+	switch page.Parent.Type {
+	case notionapi.ParentTypePageID:
+		l.parents[string(page.ID)] = string(page.Parent.PageID)
+	case notionapi.ParentTypeDatabaseID:
+		l.parents[string(page.ID)] = string(page.Parent.DatabaseID)
+	}
+}
+
+// ancestors 검색 API로 확인한 상위 페이지·데이터베이스 ID를 가까운 순서로 반환합니다
+func (l *Loader) ancestors(pageID string) []string {
+	var ids []string
+	seen := map[string]bool{pageID: true}
+	for id, ok := l.parents[pageID]; ok && !seen[id]; id, ok = l.parents[id] {
+		ids = append(ids, id)
+		seen[id] = true
+	}
+	return ids
+}
+
+// blockStats 페이지를 읽는 동안 가져온 블록 수를 집계합니다 (잘림 여부 확인용)
+type blockStats struct {
+	blocks       int // 읽은 블록 수
+	requests     int // GetChildren 호출 수 (페이지네이션 포함)
+	depthLimited int // 최대 깊이 제한으로 읽지 않은 하위 블록 수
+}
+
+// pageContent 페이지의 본문 텍스트와 메타데이터를 구성합니다
+// 데이터베이스 행이면 속성을 본문 앞에 붙이고 메타데이터에도 추가합니다
+func (l *Loader) pageContent(ctx context.Context, page notionapi.Page) (string, map[string]string, error) {
+	// 페이지 블록 가져오기 (PageID를 BlockID로 변환)
+	content, err := l.fetchPageContent(ctx, notionapi.BlockID(page.ID))
+	if err != nil {
+		return "", nil, err
+	}
+
+	meta := pageMeta(page)
+	if ancestors := l.ancestors(string(page.ID)); len(ancestors) > 0 {
+		meta["ancestors"] = strings.Join(ancestors, ",")
+	}
+
+	propsText, propsMeta := l.rowProperties(ctx, page)
+	for k, v := range propsMeta {
+		meta[k] = v
+	}
+	if propsText != "" {
+		if strings.TrimSpace(content) == "" {
+			content = propsText
+		} else {
+			content = propsText + "\n\n" + content
+		}
+	}
+
+	return content, meta, nil
+}
+
 // fetchPageContent 페이지의 모든 블록을 재귀적으로 가져와서 텍스트로 변환합니다
 func (l *Loader) fetchPageContent(ctx context.Context, pageID notionapi.BlockID) (string, error) {
 	var contentParts []string
+	var stats blockStats
 
-	err := l.fetchBlocksRecursive(ctx, pageID, &contentParts, 0)
+	err := l.fetchBlocksRecursive(ctx, pageID, &contentParts, 0, &stats)
 	if err != nil {
 		return "", err
 	}
 
+	// 페이지별 블록 수 보고
+	fmt.Printf("  블록 수: %d개 (요청 %d회)\n", stats.blocks, stats.requests)
+	if stats.depthLimited > 0 {
+		fmt.Printf("  [경고] 최대 깊이 제한으로 %d개 블록의 하위 블록을 읽지 않았습니다.\n", stats.depthLimited)
+	}
+
 	result := strings.Join(contentParts, "\n\n")
 
 	// 디버깅: 빈 콘텐츠 경고
@@ -242,33 +325,65 @@ func (l *Loader) fetchPageContent(ctx context.Context, pageID notionapi.BlockID)
 	return result, nil
 }
 
+// fetchChildren 블록의 모든 자식 블록을 NextCursor를 따라가며 가져옵니다
+func (l *Loader) fetchChildren(ctx context.Context, blockID notionapi.BlockID, stats *blockStats) ([]notionapi.Block, error) {
+	var children []notionapi.Block
+	var cursor notionapi.Cursor
+
+	for {
+		pagination := &notionapi.Pagination{
+			PageSize: 100,
+		}
+		if cursor != "" {
+			pagination.StartCursor = cursor
+		}
+
+		resp, err := l.client.Block.GetChildren(ctx, blockID, pagination)
+		if err != nil {
+			return nil, err
+		}
+		stats.requests++
+
+		children = append(children, resp.Results...)
+
+		if !resp.HasMore || resp.NextCursor == "" {
+			break
+		}
+
+		cursor = notionapi.Cursor(resp.NextCursor)
+		time.Sleep(rateLimitDelay)
+	}
+
+	stats.blocks += len(children)
+	return children, nil
+}
+
 // fetchBlocksRecursive 블록을 재귀적으로 가져와서 텍스트를 추출합니다
-func (l *Loader) fetchBlocksRecursive(ctx context.Context, blockID notionapi.BlockID, contentParts *[]string, depth int) error {
+func (l *Loader) fetchBlocksRecursive(ctx context.Context, blockID notionapi.BlockID, contentParts *[]string, depth int, stats *blockStats) error {
 	// 최대 깊이 제한 (무한 재귀 방지)
 	if depth > 20 {
+		stats.depthLimited++
 		return nil
 	}
 
-	blocks, err := l.client.Block.GetChildren(ctx, blockID, &notionapi.Pagination{
-		PageSize: 100,
-	})
+	blocks, err := l.fetchChildren(ctx, blockID, stats)
 	if err != nil {
 		return err
 	}
 
-	for _, block := range blocks.Results {
+	for _, block := range blocks {
 		// ChildPageBlock이나 LinkToPageBlock은 다른 페이지를 가리키므로 재귀하지 않음
 		switch block.(type) {
 		case *notionapi.ChildPageBlock, *notionapi.ChildDatabaseBlock:
 			// 하위 페이지나 데이터베이스는 링크만 표시하고 재귀하지 않음
-			text := extractTextFromBlock(block, depth)
+			text := extractTextFromBlock(block)
 			if text != "" {
 				*contentParts = append(*contentParts, text)
 			}
 			continue
 		}
 
-		text := extractTextFromBlock(block, depth)
+		text := extractTextFromBlock(block)
 		if text != "" {
 			*contentParts = append(*contentParts, text)
 		} else {
@@ -284,7 +399,7 @@ func (l *Loader) fetchBlocksRecursive(ctx context.Context, blockID notionapi.Blo
 			// 페이지 링크 블록이 아닌 경우에만 재귀
 			if _, isChildPage := block.(*notionapi.ChildPageBlock); !isChildPage {
 				if _, isChildDB := block.(*notionapi.ChildDatabaseBlock); !isChildDB {
-					if err := l.fetchBlocksRecursive(ctx, block.GetID(), contentParts, depth+1); err != nil {
+					if err := l.fetchBlocksRecursive(ctx, block.GetID(), contentParts, depth+1, stats); err != nil {
 						return err
 					}
 				}
@@ -297,12 +412,12 @@ func (l *Loader) fetchBlocksRecursive(ctx context.Context, blockID notionapi.Blo
 }
 
 // extractTextFromBlock 블록에서 텍스트를 추출합니다
-func extractTextFromBlock(block notionapi.Block, depth int) string {
-	prefix := strings.Repeat("#", depth+1) + " "
-
+// 제목은 # 표시, 목록은 - 표시, 코드는 ``` 로 감싸서 청커가 구조를 알 수 있도록 합니다
+func extractTextFromBlock(block notionapi.Block) string {
 	switch b := block.(type) {
 	case *notionapi.ParagraphBlock:
-		return prefix + extractRichText(b.Paragraph.RichText)
+		// 문단은 제목과 구분되도록 표시 없이 본문만 사용
+		return extractRichText(b.Paragraph.RichText)
 	case *notionapi.Heading1Block:
 		return "# " + extractRichText(b.Heading1.RichText)
 	case *notionapi.Heading2Block:
@@ -404,26 +519,6 @@ func extractRichText(richText []notionapi.RichText) string {
 	return strings.Join(parts, "")
 }
 
-// chunkText 텍스트를 지정된 크기로 청킹합니다
-func chunkText(text string, size int) []string {
-	if len(text) <= size {
-		return []string{text}
-	}
-
-	var chunks []string
-	runes := []rune(text)
-
-	for i := 0; i < len(runes); i += size {
-		end := i + size
-		if end > len(runes) {
-			end = len(runes)
-		}
-		chunks = append(chunks, string(runes[i:end]))
-	}
-
-	return chunks
-}
-
 // min 두 정수 중 작은 값을 반환합니다
 func min(a, b int) int {
 	if a < b {
@@ -448,6 +543,15 @@ func getPageTitle(page notionapi.Page) string {
 		}
 	}
 
+	// 데이터베이스마다 제목 속성 이름이 다르므로 (예: "작업", "Task") 타입으로 찾기
+	for _, prop := range props {
+		if title, ok := prop.(*notionapi.TitleProperty); ok {
+			if text := extractRichText(title.Title); text != "" {
+				return text
+			}
+		}
+	}
+
 	return "제목 없음"
 }
 
@@ -455,3 +559,31 @@ func getPageTitle(page notionapi.Page) string {
 func getPageURL(page notionapi.Page) string {
 	return fmt.Sprintf("https://www.notion.so/%s", strings.ReplaceAll(string(page.ID), "-", ""))
 }
+
+// PageLastEdit 페이지의 마지막 수정 시각을 메타데이터와 같은 형식(RFC3339)으로 반환합니다
+func PageLastEdit(page notionapi.Page) string {
+	return page.LastEditedTime.Format(time.RFC3339)
+}
+
+// pageMeta 페이지의 공통 메타데이터를 구성합니다
+func pageMeta(page notionapi.Page) map[string]string {
+	return map[string]string{
+		"page_id":   string(page.ID),
+		"title":     getPageTitle(page),
+		"url":       getPageURL(page),
+		"created":   page.CreatedTime.Format(time.RFC3339),
+		"last_edit": PageLastEdit(page),
+	}
+}
+
+// pageInfo 페이지 동기화 상태를 구성합니다
+func pageInfo(page notionapi.Page, chunkCount int) models.PageInfo {
+	return models.PageInfo{
+		ID:         string(page.ID),
+		Title:      getPageTitle(page),
+		URL:        getPageURL(page),
+		Created:    page.CreatedTime.Format(time.RFC3339),
+		LastEdit:   PageLastEdit(page),
+		ChunkCount: chunkCount,
+	}
+}
@@ -0,0 +1,222 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jomei/notionapi"
+)
+
+// propertyMetaPrefix 데이터베이스 속성을 Document.Meta에 저장할 때 사용하는 키 접두사
+const propertyMetaPrefix = "prop:"
+
+// isDatabaseRow 페이지가 데이터베이스의 행인지 확인합니다
+func isDatabaseRow(page notionapi.Page) bool {
+	return page.Parent.Type == notionapi.ParentTypeDatabaseID
+}
+
+// rowProperties 데이터베이스 행의 속성을 본문 텍스트와 메타데이터로 변환합니다
+// 제목 속성은 이미 Title로 저장되므로 제외합니다
+func (l *Loader) rowProperties(ctx context.Context, page notionapi.Page) (string, map[string]string) {
+	if !isDatabaseRow(page) {
+		return "", nil
+	}
+
+	meta := map[string]string{
+		"database_id": string(page.Parent.DatabaseID),
+	}
+	if dbTitle := l.databaseTitle(ctx, page.Parent.DatabaseID); dbTitle != "" {
+		meta["database_title"] = dbTitle
+	}
+
+	// 속성 순서를 고정하기 위해 이름순 정렬
+	names := make([]string, 0, len(page.Properties))
+	for name := range page.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		value := l.propertyValue(ctx, page.Properties[name], l.rawProps.lookup(string(page.ID), name))
+		if value == "" {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", name, value))
+		meta[propertyMetaPrefix+name] = value
+	}
+
+	if len(lines) == 0 {
+		return "", meta
+	}
+
+	text := "## 속성"
+	if title, ok := meta["database_title"]; ok {
+		text = fmt.Sprintf("## 속성 (데이터베이스: %s)", title)
+	}
+	return text + "\n" + strings.Join(lines, "\n"), meta
+}
+
+// propertyValue 속성 값을 사람이 읽을 수 있는 문자열로 변환합니다
+// raw는 검색 응답의 원본 속성 정보이며, 없으면(nil) notionapi가 디코딩한 값만으로 변환합니다
+func (l *Loader) propertyValue(ctx context.Context, prop notionapi.Property, raw *rawProperty) string {
+	switch p := prop.(type) {
+	case *notionapi.TitleProperty:
+		// 제목은 Document.Title로 따로 저장됨
+		return ""
+	case *notionapi.RichTextProperty:
+		return extractRichText(p.RichText)
+	case *notionapi.NumberProperty:
+		// notionapi는 비어있는 숫자(null)를 0으로 디코딩하므로 원본에서 null인지 확인 (0은 그대로 표시)
+		if raw != nil && raw.Number == nil {
+			return ""
+		}
+		return strconv.FormatFloat(float64(p.Number), 'f', -1, 64)
+	case *notionapi.SelectProperty:
+		return p.Select.Name
+	case *notionapi.MultiSelectProperty:
+		var names []string
+		for _, opt := range p.MultiSelect {
+			names = append(names, opt.Name)
+		}
+		return strings.Join(names, ", ")
+	case *notionapi.StatusProperty:
+		return p.Status.Name
+	case *notionapi.PeopleProperty:
+		var names []string
+		for _, user := range p.People {
+			if user.Name != "" {
+				names = append(names, user.Name)
+			}
+		}
+		return strings.Join(names, ", ")
+	case *notionapi.DateProperty:
+		var date *rawDate
+		if raw != nil {
+			date = raw.Date
+		}
+		return formatDateObject(p.Date, date)
+	case *notionapi.CheckboxProperty:
+		// 체크하지 않은 체크박스는 모든 행에 붙어 검색을 흐리므로 체크한 경우만 표시
+		if p.Checkbox {
+			return "예"
+		}
+		return ""
+	case *notionapi.URLProperty:
+		return p.URL
+	case *notionapi.EmailProperty:
+		return p.Email
+	case *notionapi.PhoneNumberProperty:
+		return p.PhoneNumber
+	case *notionapi.RelationProperty:
+		var titles []string
+		for _, rel := range p.Relation {
+			titles = append(titles, l.relatedPageTitle(ctx, rel.ID))
+		}
+		return strings.Join(titles, ", ")
+	case *notionapi.FormulaProperty:
+		switch {
+		case p.Formula.String != "":
+			return p.Formula.String
+		case p.Formula.Date != nil:
+			var date *rawDate
+			if raw != nil && raw.Formula != nil {
+				date = raw.Formula.Date
+			}
+			return formatDateObject(p.Formula.Date, date)
+		case p.Formula.Type == notionapi.FormulaTypeNumber:
+			return strconv.FormatFloat(float64(p.Formula.Number), 'f', -1, 64)
+		case p.Formula.Type == notionapi.FormulaTypeBoolean:
+			if p.Formula.Boolean {
+				return "예"
+			}
+			return "아니오"
+		}
+		return ""
+	case *notionapi.CreatedByProperty:
+		return p.CreatedBy.Name
+	case *notionapi.LastEditedByProperty:
+		return p.LastEditedBy.Name
+	default:
+		// 생성/수정 시각은 created, last_edit 메타데이터로 이미 저장됨
+		// 그 밖의 속성(rollup, files 등)은 건너뜀
+		return ""
+	}
+}
+
+// formatDateObject 날짜 속성을 "2006-01-02" 또는 "2006-01-02 ~ 2006-01-02" 형식으로 변환합니다
+// raw가 있으면 원본 문자열로 시각 포함 여부를 판단합니다
+func formatDateObject(date *notionapi.DateObject, raw *rawDate) string {
+	if date == nil || date.Start == nil {
+		return ""
+	}
+
+	var rawStart, rawEnd *string
+	if raw != nil {
+		rawStart, rawEnd = &raw.Start, raw.End
+	}
+
+	start := formatNotionDate(date.Start, rawStart)
+	if date.End == nil {
+		return start
+	}
+	return start + " ~ " + formatNotionDate(date.End, rawEnd)
+}
+
+// formatNotionDate Notion 날짜를 문자열로 변환합니다 (시각이 있으면 RFC3339, 없으면 날짜만)
+// 원본 문자열(raw)이 없으면 자정인지로 시각 포함 여부를 추정합니다
+func formatNotionDate(date *notionapi.Date, raw *string) string {
+	t := time.Time(*date)
+	withTime := t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0
+	if raw != nil {
+		withTime = hasTime(*raw)
+	}
+	if !withTime {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// databaseTitle 데이터베이스 제목을 조회합니다 (한 번 조회한 제목은 캐시)
+func (l *Loader) databaseTitle(ctx context.Context, databaseID notionapi.DatabaseID) string {
+	key := string(databaseID)
+	if title, ok := l.titleCache[key]; ok {
+		return title
+	}
+
+	title := ""
+	database, err := l.client.Database.Get(ctx, databaseID)
+	if err != nil {
+		fmt.Printf("  [경고] 데이터베이스 %s 조회 실패: %v\n", databaseID, err)
+	} else {
+		title = extractRichText(database.Title)
+	}
+	time.Sleep(rateLimitDelay)
+
+	l.titleCache[key] = title
+	return title
+}
+
+// relatedPageTitle 관계(relation) 속성이 가리키는 페이지의 제목을 조회합니다 (한 번 조회한 제목은 캐시)
+func (l *Loader) relatedPageTitle(ctx context.Context, pageID notionapi.PageID) string {
+	key := string(pageID)
+	if title, ok := l.titleCache[key]; ok {
+		return title
+	}
+
+	title := key
+	page, err := l.client.Page.Get(ctx, pageID)
+	if err != nil {
+		fmt.Printf("  [경고] 관계 페이지 %s 조회 실패: %v\n", pageID, err)
+	} else {
+		title = getPageTitle(*page)
+	}
+	time.Sleep(rateLimitDelay)
+
+	l.titleCache[key] = title
+	return title
+}
@@ -0,0 +1,76 @@
+package notion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jomei/notionapi"
+)
+
+// searchResponse 데이터베이스 행 하나가 들어있는 Search API 응답
+const searchResponse = `{
+	"object": "list",
+	"results": [{
+		"object": "page",
+		"id": "row-1",
+		"properties": {
+			"비어있는 숫자": {"id": "a", "type": "number", "number": null},
+			"숫자 0": {"id": "b", "type": "number", "number": 0},
+			"숫자": {"id": "c", "type": "number", "number": 2.5},
+			"날짜": {"id": "d", "type": "date", "date": {"start": "2024-01-05", "end": null}},
+			"자정 시각": {"id": "e", "type": "date", "date": {"start": "2024-01-05T00:00:00.000+09:00", "end": null}},
+			"기간": {"id": "f", "type": "date", "date": {"start": "2024-01-05", "end": "2024-01-07"}}
+		}
+	}],
+	"has_more": false
+}`
+
+// TestPropertyValueRaw 검색 응답의 원본 속성 정보로 비어있는 숫자와 0을, 날짜와 자정 시각을 구분하는지 확인합니다
+func TestPropertyValueRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(searchResponse))
+	}))
+	defer server.Close()
+
+	recorder := newPropertyRecorder(http.DefaultTransport)
+	client := &http.Client{Transport: recorder}
+	resp, err := client.Post(server.URL+"/v1/search", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// 기록한 뒤에도 notionapi가 응답 본문을 그대로 디코딩할 수 있어야 함
+	var decoded struct {
+		Results []notionapi.Page `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Results) != 1 {
+		t.Fatalf("페이지 %d개, 기대 1개", len(decoded.Results))
+	}
+	page := decoded.Results[0]
+
+	tests := map[string]string{
+		"비어있는 숫자": "",
+		"숫자 0":    "0",
+		"숫자":      "2.5",
+		"날짜":      "2024-01-05",
+		"자정 시각":   "2024-01-05T00:00:00+09:00",
+		"기간":      "2024-01-05 ~ 2024-01-07",
+	}
+
+	l := &Loader{}
+	for name, want := range tests {
+		got := l.propertyValue(context.Background(), page.Properties[name], recorder.lookup(string(page.ID), name))
+		if got != want {
+			t.Errorf("%s = %q, 기대 %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package notion
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// rawDate 원본 JSON의 날짜 값 (시각 포함 여부를 문자열로 확인)
+type rawDate struct {
+	Start string  `json:"start"`
+	End   *string `json:"end"`
+}
+
+// rawProperty notionapi가 디코딩하면서 잃어버리는 속성 정보
+// notionapi는 비어있는 숫자(null)를 0으로, 날짜만 있는 값과 자정 시각을 같은 time.Time으로 디코딩하므로 원본 JSON에서 따로 확인합니다
+type rawProperty struct {
+	Number  *float64 `json:"number"`
+	Date    *rawDate `json:"date"`
+	Formula *struct {
+		Date *rawDate `json:"date"`
+	} `json:"formula"`
+}
+
+// propertyRecorder Search API 응답에서 페이지별 원본 속성 정보를 기록하는 http.RoundTripper
+// 응답 본문은 그대로 notionapi에 넘기므로 디코딩 결과에는 영향이 없습니다
+type propertyRecorder struct {
+	base  http.RoundTripper
+	mu    sync.Mutex
+	pages map[string]map[string]rawProperty // 페이지 ID → 속성 이름 → 원본 속성 정보
+}
+
+// newPropertyRecorder 새로운 원본 속성 기록기를 생성합니다
+func newPropertyRecorder(base http.RoundTripper) *propertyRecorder {
+	return &propertyRecorder{
+		base:  base,
+		pages: make(map[string]map[string]rawProperty),
+	}
+}
+
+// RoundTrip 요청을 보내고 Search API 응답이면 본문을 읽어서 페이지별 속성 정보를 기록합니다
+func (r *propertyRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.HasSuffix(req.URL.Path, "/search") {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.record(body)
+	return resp, nil
+}
+
+// record Search API 응답 본문에서 페이지별 속성 정보를 기록합니다 (형식이 다르면 무시)
+func (r *propertyRecorder) record(body []byte) {
+	var resp struct {
+		Results []struct {
+			Object     string                 `json:"object"`
+			ID         string                 `json:"id"`
+			Properties map[string]rawProperty `json:"properties"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, result := range resp.Results {
+		if result.Object == "page" {
+			r.pages[result.ID] = result.Properties
+		}
+	}
+}
+
+// lookup 페이지 속성의 원본 정보를 반환합니다 (기록되지 않았으면 nil)
+func (r *propertyRecorder) lookup(pageID, name string) *rawProperty {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	raw, ok := r.pages[pageID][name]
+	if !ok {
+		return nil
+	}
+	return &raw
+}
+
+// hasTime 원본 날짜 문자열에 시각이 있는지 확인합니다 (예: "2024-01-05T00:00:00.000+09:00")
+func hasTime(date string) bool {
+	return strings.Contains(date, "T")
+}
@@ -0,0 +1,67 @@
+package rag
+
+import (
+	"time"
+
+	"goc-notion-rag/models"
+)
+
+// Answer RAG 검색 한 번의 결과 (답변, 출처, 검색된 청크, 사용한 모델, 소요 시간, 토큰 수)
+// REPL 등 화면에 보여주는 쪽은 이 값을 그대로 렌더링하며, 로그·평가·JSON 출력에도 사용할 수 있습니다
+type Answer struct {
+	Question     string                `json:"question"`
+	Query        string                `json:"query"`        // 검색에 사용한 질문 (후속 질문을 재작성했으면 독립적인 질문, 아니면 Question과 같음)
+	Queries      []string              `json:"queries"`      // 함께 검색한 추가 검색 질문 (multi_query를 사용하지 않으면 비어있음)
+	Hypothetical string                `json:"hypothetical"` // 벡터 검색에 사용한 HyDE 가상 문서 (HyDE를 사용하지 않으면 비어있음)
+	Text         string                `json:"text"`         // 답변 본문 (잘못된 출처 표시를 지운 뒤, 출처 목록은 포함하지 않음)
+	Citations    []Source              `json:"citations"`    // 답변에서 인용한 출처 (번호순)
+	Sources      []Source              `json:"sources"`      // 프롬프트에 넣은 모든 출처 (번호순)
+	Chunks       []models.SearchResult `json:"chunks"`       // 검색된 청크와 점수 (확장 전)
+	Context      ContextUsage          `json:"context"`      // 컨텍스트 토큰 예산과 제외한 문서
+	Prompt       string                `json:"prompt"`       // 모델에 보낸 프롬프트
+	Model        string                `json:"model"`        // 답변 생성 모델
+	Timings      Timings               `json:"timings"`
+	Usage        Usage                 `json:"usage"`
+}
+
+// Timings 단계별 소요 시간
+type Timings struct {
+	Rewrite  time.Duration `json:"rewrite"`  // 후속 질문 재작성(대화 세션에서만), 가상 문서와 추가 검색 질문 생성
+	Retrieve time.Duration `json:"retrieve"` // 질문 임베딩, 검색, 재순위, 다양화
+	Generate time.Duration `json:"generate"` // 답변 생성 (재시도 대기 포함)
+	Total    time.Duration `json:"total"`
+}
+
+// Usage 답변 생성(과 질문 재작성)에 사용한 토큰 수 (모델이 알려주지 않으면 0)
+type Usage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// add 두 요청의 토큰 수를 합칩니다
+func (u Usage) add(other Usage) Usage {
+	return Usage{
+		PromptTokens: u.PromptTokens + other.PromptTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		TotalTokens:  u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// FormatText 답변 본문 뒤에 출처 목록을 붙인 텍스트를 반환합니다 (출처가 없으면 본문만)
+func (a *Answer) FormatText() string {
+	sources := a.FormatSources()
+	if sources == "" {
+		return a.Text
+	}
+	return a.Text + "\n\n" + sources
+}
+
+// FormatSources 인용한 출처의 제목과 Notion URL 목록을 반환합니다 (인용이 없으면 모든 출처, 출처가 없으면 빈 문자열)
+func (a *Answer) FormatSources() string {
+	cited := make([]int, len(a.Citations))
+	for i, source := range a.Citations {
+		cited[i] = source.Number
+	}
+	return formatSources(a.Sources, cited)
+}
@@ -0,0 +1,183 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"goc-notion-rag/embedding"
+	"goc-notion-rag/models"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// DefaultContextTokens 프롬프트 컨텍스트(검색된 문서)에 사용할 최대 토큰 수 기본값
+const DefaultContextTokens = 8000
+
+// TokenizerGemini 컨텍스트 토큰 수를 Gemini CountTokens API로 계산 (문서마다 요청 한 번)
+const TokenizerGemini = "gemini"
+
+// minTrimTokens 예산을 넘는 문서를 잘라서 넣을 때 남은 예산의 최솟값 (이보다 적으면 자르지 않고 제외)
+const minTrimTokens = 50
+
+// truncationMark 뒷부분을 자른 문서 끝에 붙이는 표시
+const truncationMark = "..."
+
+// contextSeparator buildContext가 문서 사이에 넣는 구분자
+const contextSeparator = "\n\n---\n\n"
+
+// ContextUsage 프롬프트 컨텍스트에 넣은 문서와 토큰 예산
+type ContextUsage struct {
+	Budget    int               `json:"budget"`    // 컨텍스트 토큰 예산 (0이면 제한 없음)
+	Tokens    int               `json:"tokens"`    // 컨텍스트에 넣은 문서의 토큰 수 (예산이 없으면 0)
+	Documents int               `json:"documents"` // 컨텍스트에 넣은 문서 수
+	Truncated bool              `json:"truncated"` // 마지막 문서의 뒷부분을 잘랐는지
+	Dropped   []DroppedDocument `json:"dropped"`   // 예산을 넘어서 넣지 못한 문서 (순위순)
+}
+
+// DroppedDocument 토큰 예산을 넘어서 컨텍스트에서 제외한 문서
+type DroppedDocument struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Tokens int    `json:"tokens"`
+}
+
+// newContextTokenizer 컨텍스트 토큰 수를 계산할 토크나이저를 생성합니다
+// gemini는 답변 모델의 CountTokens API를, 나머지는 임베딩 토크나이저(estimate, runes)를 사용합니다
+func newContextTokenizer(ctx context.Context, name string, gemini func() (*geminiModels, error)) (embedding.Tokenizer, error) {
+	if name == TokenizerGemini {
+		return &geminiTokenizer{gemini: gemini, ctx: ctx}, nil
+	}
+	tokenizer, err := embedding.NewTokenizer(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s도 사용 가능)", err, TokenizerGemini)
+	}
+	return tokenizer, nil
+}
+
+// geminiTokenizer Gemini CountTokens API로 토큰 수를 계산하는 토크나이저
+// API 요청이 실패하면 근사치(EstimateTokenizer)를 사용합니다
+type geminiTokenizer struct {
+	gemini func() (*geminiModels, error)
+	ctx    context.Context
+}
+
+// withContext 요청 ctx로 CountTokens를 호출하는 복사본을 반환합니다 (요청이 취소되면 API 요청도 중단)
+func (t *geminiTokenizer) withContext(ctx context.Context) embedding.Tokenizer {
+	return &geminiTokenizer{gemini: t.gemini, ctx: ctx}
+}
+
+// requestTokenizer 요청 ctx를 쓰는 컨텍스트 토크나이저를 반환합니다 (API를 호출하지 않는 토크나이저는 그대로)
+func (s *Searcher) requestTokenizer(ctx context.Context) embedding.Tokenizer {
+	if tok, ok := s.tokenizer.(*geminiTokenizer); ok {
+		return tok.withContext(ctx)
+	}
+	return s.tokenizer
+}
+
+// CountTokens 답변 모델 기준의 토큰 수를 반환합니다
+func (t *geminiTokenizer) CountTokens(text string) int {
+	gemini, err := t.gemini()
+	if err != nil {
+		return embedding.EstimateTokenizer{}.CountTokens(text)
+	}
+	resp, err := gemini.answer.CountTokens(t.ctx, genai.Text(text))
+	if err != nil {
+		return embedding.EstimateTokenizer{}.CountTokens(text)
+	}
+	return int(resp.TotalTokens)
+}
+
+// packContext 순위가 높은 문서부터 토큰 예산 안에 들어가는 만큼 컨텍스트에 넣습니다
+// 예산을 처음 넘는 문서는 남은 예산만큼 뒷부분을 잘라서 넣고 (남은 예산이 너무 적으면 제외), 그 뒤의 문서는 모두 제외합니다
+// 첫 문서가 예산보다 크면 잘라서라도 넣습니다
+// API로 세는 토크나이저도 요청이 적도록 구분자는 한 번만, 출처 머리말은 본문과 함께 문서마다 한 번에 세고, 자를 때만 따로 셉니다
+func (s *Searcher) packContext(ctx context.Context, documents []*models.Document) ([]*models.Document, ContextUsage) {
+	usage := ContextUsage{Budget: s.config.ContextTokens}
+	if usage.Budget <= 0 {
+		usage.Budget = 0
+		usage.Documents = len(documents)
+		return documents, usage
+	}
+
+	tokenizer := s.requestTokenizer(ctx)
+
+	separatorTokens := 0
+	if len(documents) > 1 {
+		separatorTokens = tokenizer.CountTokens(contextSeparator)
+	}
+
+	var packed []*models.Document
+	for i, doc := range documents {
+		// buildContext의 출처 머리말과 구분자도 함께 계산
+		header := fmt.Sprintf("[%d] %s\n", len(packed)+1, doc.Title)
+		tokens := tokenizer.CountTokens(header + doc.Content)
+		separator := 0
+		if len(packed) > 0 {
+			separator = separatorTokens
+		}
+
+		remaining := usage.Budget - usage.Tokens
+		if separator+tokens <= remaining {
+			packed = append(packed, doc)
+			usage.Tokens += separator + tokens
+			continue
+		}
+
+		// 예산을 처음 넘는 문서는 뒷부분을 잘라서 넣음
+		rest := documents[i:]
+		overhead := separator + tokenizer.CountTokens(header)
+		if remaining-overhead >= minTrimTokens || len(packed) == 0 {
+			markTokens := tokenizer.CountTokens(truncationMark)
+			content, contentTokens := trimToTokens(tokenizer, doc.Content, separator+tokens-overhead, remaining-overhead-markTokens)
+			if content != "" {
+				trimmed := *doc
+				trimmed.Content = content + truncationMark
+				packed = append(packed, &trimmed)
+				usage.Tokens += overhead + contentTokens + markTokens
+				usage.Truncated = true
+				rest = documents[i+1:]
+			}
+		}
+
+		for _, dropped := range rest {
+			usage.Dropped = append(usage.Dropped, DroppedDocument{
+				ID:     dropped.ID,
+				Title:  dropped.Title,
+				Tokens: tokenizer.CountTokens(dropped.Content),
+			})
+		}
+		break
+	}
+
+	usage.Documents = len(packed)
+	return packed, usage
+}
+
+// trimToTokens 토큰 수가 total인 텍스트의 뒷부분을 잘라 maxTokens 이하로 만들고 잘라낸 텍스트의 토큰 수와 함께 반환합니다
+// 가능하면 공백 경계에서 자르며, 남는 것이 없으면 빈 문자열을 반환합니다
+// 토큰 비율로 자를 위치를 정한 뒤 넘으면 10%씩 줄여서 다시 세므로, API로 세는 토크나이저도 몇 번의 요청으로 끝납니다
+func trimToTokens(tok embedding.Tokenizer, text string, total, maxTokens int) (string, int) {
+	if maxTokens <= 0 {
+		return "", 0
+	}
+	if total <= maxTokens {
+		return text, total
+	}
+
+	runes := []rune(text)
+	n := len(runes) * maxTokens / total
+	for n > 0 {
+		prefix := string(runes[:n])
+		if idx := strings.LastIndexFunc(prefix, unicode.IsSpace); idx > len(prefix)/2 {
+			prefix = prefix[:idx]
+		}
+		prefix = strings.TrimSpace(prefix)
+		if tokens := tok.CountTokens(prefix); tokens <= maxTokens {
+			return prefix, tokens
+		}
+		n = n * 9 / 10
+	}
+	return "", 0
+}
@@ -0,0 +1,62 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"goc-notion-rag/embedding"
+	"goc-notion-rag/models"
+)
+
+// countingTokenizer 글자 수로 토큰을 세면서 호출 횟수를 기록하는 테스트용 토크나이저 (API 요청 횟수 대신)
+type countingTokenizer struct {
+	calls int
+}
+
+// CountTokens 호출 횟수를 늘리고 글자 수를 반환합니다
+func (t *countingTokenizer) CountTokens(text string) int {
+	t.calls++
+	return embedding.RuneTokenizer{}.CountTokens(text)
+}
+
+// TestPackContextCountCalls 예산 안에 들어가는 문서는 문서마다 한 번만 세는지, 자른 문서까지 예산을 지키는지 확인합니다
+func TestPackContextCountCalls(t *testing.T) {
+	documents := make([]*models.Document, 5)
+	for i := range documents {
+		documents[i] = &models.Document{ID: string(rune('a' + i)), Title: "제목", Content: strings.Repeat("내용 ", 40)}
+	}
+
+	tests := []struct {
+		name      string
+		budget    int
+		packed    int
+		truncated bool
+		dropped   int
+		calls     int
+	}{
+		// 구분자 1번 + 문서 5번
+		{"모두 들어감", 10000, 5, false, 0, 6},
+		// 구분자 1번 + 문서 3번 + 자를 때 머리말·표시·잘라낸 본문 1번씩 + 제외 문서 2번
+		{"세 번째 문서를 자름", 340, 3, true, 2, 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := &countingTokenizer{}
+			s := &Searcher{config: Config{ContextTokens: tt.budget}, tokenizer: tokenizer}
+
+			packed, usage := s.packContext(context.Background(), documents)
+			if len(packed) != tt.packed || usage.Truncated != tt.truncated || len(usage.Dropped) != tt.dropped {
+				t.Fatalf("문서 %d개 (잘림 %v, 제외 %d개), 기대 %d개 (잘림 %v, 제외 %d개)",
+					len(packed), usage.Truncated, len(usage.Dropped), tt.packed, tt.truncated, tt.dropped)
+			}
+			if usage.Tokens > tt.budget {
+				t.Errorf("예산 %d 토큰을 넘었습니다: %d", tt.budget, usage.Tokens)
+			}
+			if tokenizer.calls != tt.calls {
+				t.Errorf("토큰 계산 %d번, 기대 %d번", tokenizer.calls, tt.calls)
+			}
+		})
+	}
+}
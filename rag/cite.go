@@ -0,0 +1,261 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"goc-notion-rag/models"
+)
+
+// citationPattern 답변 안의 출처 표시 (예: "[1]", "[2, 3]")
+// 한 줄 안에서만 찾으며, 코드나 배열 첨자인지는 citationAllowed로 따로 확인합니다
+var citationPattern = regexp.MustCompile(`\[(\d+(?:[ \t]*,[ \t]*\d+)*)\]`)
+
+// Source 답변의 출처로 번호를 붙인 Notion 페이지
+type Source struct {
+	Number int    `json:"number"`  // 프롬프트와 답변에서 사용하는 출처 번호 (1부터 시작)
+	PageID string `json:"page_id"` // 원본 페이지 ID
+	Title  string `json:"title"`   // 페이지 제목
+	URL    string `json:"url"`     // Notion URL
+}
+
+// numberSources 컨텍스트 문서에 페이지 단위로 출처 번호를 붙입니다
+// 같은 페이지의 문서는 같은 번호를 사용하며, 반환하는 numbers는 documents와 같은 순서의 출처 번호입니다
+func numberSources(documents []*models.Document) ([]Source, []int) {
+	var sources []Source
+	numbers := make([]int, len(documents))
+	byPage := make(map[string]int)
+
+	for i, doc := range documents {
+		key := pageKey(doc)
+		if number, ok := byPage[key]; ok {
+			numbers[i] = number
+			continue
+		}
+
+		title := doc.Title
+		if title == "" {
+			title = "제목 없음"
+		}
+		source := Source{
+			Number: len(sources) + 1,
+			PageID: doc.ParentPageID,
+			Title:  title,
+			URL:    doc.Meta["url"],
+		}
+		sources = append(sources, source)
+		byPage[key] = source.Number
+		numbers[i] = source.Number
+	}
+
+	return sources, numbers
+}
+
+// validateCitations 답변의 출처 표시 중 존재하지 않는 번호를 지우고, 실제로 인용한 출처 번호를 오름차순으로 반환합니다
+// [1, 9]처럼 일부만 잘못된 경우 올바른 번호만 남기고, 모두 잘못되었으면 표시 전체를 앞의 공백과 함께 지웁니다
+// 코드 블록(```)과 인라인 코드(`...`) 안, 그리고 items[0]처럼 영문·숫자 바로 뒤의 대괄호는 출처 표시로 보지 않습니다
+func validateCitations(answer string, sourceCount int) (string, []int) {
+	cited := make(map[int]bool)
+
+	var sb strings.Builder
+	inFence := false
+	for _, line := range strings.SplitAfter(answer, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			sb.WriteString(line)
+			continue
+		}
+		if inFence {
+			sb.WriteString(line)
+			continue
+		}
+		sb.WriteString(replaceCitations(line, sourceCount, cited))
+	}
+
+	numbers := make([]int, 0, len(cited))
+	for number := range cited {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	return sb.String(), numbers
+}
+
+// replaceCitations 한 줄의 출처 표시에서 존재하지 않는 번호를 지우고 인용한 번호를 cited에 기록합니다
+func replaceCitations(line string, sourceCount int, cited map[int]bool) string {
+	spans := codeSpans(line)
+
+	var sb strings.Builder
+	last := 0
+	indexEnd := -1 // 바로 앞에서 첨자로 판단한 대괄호의 끝 (arr[1][2]의 [2]도 첨자로 보기 위함)
+	for _, match := range citationPattern.FindAllStringSubmatchIndex(line, -1) {
+		start, end := match[0], match[1]
+		if inSpans(start, spans) {
+			continue
+		}
+		if !citationAllowed(line, start, indexEnd) {
+			indexEnd = end
+			continue
+		}
+
+		var valid []string
+		for _, part := range strings.Split(line[match[2]:match[3]], ",") {
+			number, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || number < 1 || number > sourceCount {
+				continue
+			}
+			cited[number] = true
+			valid = append(valid, strconv.Itoa(number))
+		}
+
+		prefix := line[last:start]
+		if len(valid) == 0 {
+			// 표시를 지울 때 앞의 공백도 함께 지움
+			sb.WriteString(strings.TrimRight(prefix, " \t"))
+		} else {
+			sb.WriteString(prefix)
+			sb.WriteString("[" + strings.Join(valid, ", ") + "]")
+		}
+		last = end
+	}
+	sb.WriteString(line[last:])
+
+	return sb.String()
+}
+
+// citationAllowed start 위치의 대괄호가 출처 표시일 수 있는지 확인합니다
+// 영문·숫자·밑줄이나 닫는 괄호 바로 뒤(items[0], f()[1])와 첨자 바로 뒤의 대괄호는 첨자로 봅니다
+// 한글 등 다른 문자 뒤는 "입니다[1]"처럼 붙여 쓴 출처 표시로 봅니다
+func citationAllowed(line string, start, indexEnd int) bool {
+	if start == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(line[:start])
+	switch {
+	case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '_', r == ')':
+		return false
+	case r == ']':
+		return start != indexEnd
+	default:
+		return true
+	}
+}
+
+// codeSpans 한 줄에서 인라인 코드(같은 개수의 백틱으로 감싼 부분)의 구간 [start, end) 목록을 반환합니다
+// 짝이 없는 백틱은 일반 문자로 취급합니다
+func codeSpans(line string) [][2]int {
+	var spans [][2]int
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		run := backtickRun(line, i)
+		closing := -1
+		for j := i + run; j < len(line); {
+			if line[j] != '`' {
+				j++
+				continue
+			}
+			if n := backtickRun(line, j); n == run {
+				closing = j
+				break
+			} else {
+				j += n
+			}
+		}
+		if closing < 0 {
+			i += run
+			continue
+		}
+		spans = append(spans, [2]int{i, closing + run})
+		i = closing + run
+	}
+	return spans
+}
+
+// backtickRun i부터 이어지는 백틱 수를 반환합니다
+func backtickRun(line string, i int) int {
+	n := 0
+	for i+n < len(line) && line[i+n] == '`' {
+		n++
+	}
+	return n
+}
+
+// inSpans pos가 구간 중 하나에 들어가는지 확인합니다
+func inSpans(pos int, spans [][2]int) bool {
+	for _, span := range spans {
+		if pos >= span[0] && pos < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSources 답변 뒤에 붙일 출처 목록을 구성합니다 (인용한 출처만, 인용이 없으면 모든 출처)
+func formatSources(sources []Source, cited []int) string {
+	if len(sources) == 0 {
+		return ""
+	}
+
+	listed := sources
+	if len(cited) > 0 {
+		listed = make([]Source, 0, len(cited))
+		for _, number := range cited {
+			listed = append(listed, sources[number-1])
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("출처:")
+	for _, source := range listed {
+		fmt.Fprintf(&sb, "\n[%d] %s", source.Number, source.Title)
+		if source.URL != "" {
+			fmt.Fprintf(&sb, " - %s", source.URL)
+		}
+	}
+	return sb.String()
+}
+
+// citationFilter 스트리밍 답변을 줄 단위로 모아서 출처 표시를 검증한 뒤 전달합니다
+// 출처 표시는 한 줄 안에만 있으므로 완성된 줄까지 검증한 결과는 뒤에 오는 텍스트로 바뀌지 않습니다
+type citationFilter struct {
+	sourceCount int
+	onText      func(string)
+	raw         strings.Builder // 지금까지 받은 원본 텍스트
+	emitted     int             // 검증한 텍스트 중 이미 전달한 길이
+}
+
+// newCitationFilter 검증한 텍스트를 onText에 전달하는 필터를 생성합니다
+func newCitationFilter(sourceCount int, onText func(string)) *citationFilter {
+	return &citationFilter{sourceCount: sourceCount, onText: onText}
+}
+
+// write 받은 텍스트를 모으고, 완성된 줄이 있으면 검증해서 전달합니다
+func (f *citationFilter) write(token string) {
+	f.raw.WriteString(token)
+	raw := f.raw.String()
+	if end := strings.LastIndex(raw, "\n"); end >= 0 {
+		f.emit(raw[:end+1])
+	}
+}
+
+// flush 마지막 줄까지 검증해서 전달합니다 (생성이 끝나거나 중단된 뒤 호출)
+func (f *citationFilter) flush() {
+	f.emit(f.raw.String())
+}
+
+// emit raw를 검증한 결과 중 아직 전달하지 않은 부분을 전달합니다
+func (f *citationFilter) emit(raw string) {
+	validated, _ := validateCitations(raw, f.sourceCount)
+	if len(validated) > f.emitted {
+		f.onText(validated[f.emitted:])
+		f.emitted = len(validated)
+	}
+}
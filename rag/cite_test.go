@@ -0,0 +1,57 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateCitations(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+		cited  []int
+	}{
+		{"유효한 번호", "설치는 make로 합니다 [1]. 배포는 CI에서 합니다[2].", "설치는 make로 합니다 [1]. 배포는 CI에서 합니다[2].", []int{1, 2}},
+		{"없는 번호 삭제", "내용입니다 [9].", "내용입니다.", nil},
+		{"일부만 유효", "내용 [1, 9]", "내용 [1]", []int{1}},
+		{"이어 붙인 출처", "내용 [1][2]", "내용 [1][2]", []int{1, 2}},
+		{"배열 첨자", "items[0]과 arr[2][1]은 그대로 둡니다", "items[0]과 arr[2][1]은 그대로 둡니다", nil},
+		{"함수 호출 결과 첨자", "f()[1]", "f()[1]", nil},
+		{"인라인 코드", "`x = [0]`처럼 씁니다 [1]", "`x = [0]`처럼 씁니다 [1]", []int{1}},
+		{"코드 블록", "예시:\n```\nvalues = [9]\n```\n끝 [2]", "예시:\n```\nvalues = [9]\n```\n끝 [2]", []int{2}},
+		{"짝 없는 백틱", "따옴표 ` 뒤 [7] 삭제", "따옴표 ` 뒤 삭제", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cited := validateCitations(tt.answer, 2)
+			if got != tt.want {
+				t.Errorf("답변 = %q, 기대값 %q", got, tt.want)
+			}
+			if len(cited) == 0 {
+				cited = nil
+			}
+			if !reflect.DeepEqual(cited, tt.cited) {
+				t.Errorf("인용 = %v, 기대값 %v", cited, tt.cited)
+			}
+		})
+	}
+}
+
+func TestCitationFilterMatchesValidateCitations(t *testing.T) {
+	tokens := []string{"첫 줄 [1", "] 그리고 [9].\n```\nx = [", "5]\n```\n둘째 [2, 7]\n셋째 [3]"}
+
+	var streamed, raw string
+	filter := newCitationFilter(2, func(text string) { streamed += text })
+	for _, token := range tokens {
+		raw += token
+		filter.write(token)
+	}
+	filter.flush()
+
+	want, _ := validateCitations(raw, 2)
+	if streamed != want {
+		t.Errorf("스트리밍 결과 = %q, 기대값 %q", streamed, want)
+	}
+}
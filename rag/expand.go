@@ -0,0 +1,179 @@
+package rag
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"goc-notion-rag/models"
+)
+
+// minOverlapBytes 이어지는 청크를 합칠 때 겹친 부분으로 인정하는 최소 길이
+// 우연히 같은 글자로 끝나고 시작하는 경우를 겹침으로 잘못 판단하지 않도록 합니다
+const minOverlapBytes = 20
+
+// expandContext 검색된 청크를 앞뒤 청크나 페이지 전체로 넓혀서 프롬프트에 넣을 문서 목록을 만듭니다
+// 같은 페이지의 청크는 순서대로 정렬하고 이어지는 청크끼리 하나의 문서로 합치며,
+// 페이지는 가장 순위가 높은 청크의 순서를 따릅니다 (설정하지 않았으면 검색된 청크를 그대로 사용)
+func (s *Searcher) expandContext(ctx context.Context, results []models.SearchResult) []*models.Document {
+	if s.config.ExpandNeighbors <= 0 && s.config.ExpandPageChunks <= 0 {
+		documents := make([]*models.Document, len(results))
+		for i, result := range results {
+			documents[i] = result.Document
+		}
+		return documents
+	}
+
+	// 페이지별로 검색된 청크 순번을 모음 (청크 ID 형식이 아니면 그대로 사용)
+	type pageHits struct {
+		pageID string
+		hits   map[int]*models.Document
+		single *models.Document
+	}
+	var order []*pageHits
+	byPage := make(map[string]*pageHits)
+	for _, result := range results {
+		doc := result.Document
+		pageID, index, ok := models.ParseChunkID(doc.ID)
+		if !ok {
+			order = append(order, &pageHits{single: doc})
+			continue
+		}
+		page, exists := byPage[pageID]
+		if !exists {
+			page = &pageHits{pageID: pageID, hits: make(map[int]*models.Document)}
+			byPage[pageID] = page
+			order = append(order, page)
+		}
+		page.hits[index] = doc
+	}
+
+	var documents []*models.Document
+	for _, page := range order {
+		if page.single != nil {
+			documents = append(documents, page.single)
+			continue
+		}
+
+		chunks := s.pageChunks(ctx, page.pageID, page.hits)
+		for _, run := range contiguousRuns(chunks) {
+			documents = append(documents, mergeChunks(run))
+		}
+	}
+
+	return documents
+}
+
+// indexedChunk 페이지 안의 청크 순번과 문서
+type indexedChunk struct {
+	index int
+	doc   *models.Document
+}
+
+// pageChunks 검색된 청크와 함께 프롬프트에 넣을 같은 페이지의 청크를 순번 순서로 반환합니다
+// 페이지의 청크 수가 ExpandPageChunks 이하면 페이지 전체를, 아니면 검색된 청크의 앞뒤 ExpandNeighbors개를 가져옵니다
+// 저장되지 않은 청크(내용이 짧아 건너뛴 청크 등)는 빠집니다
+func (s *Searcher) pageChunks(ctx context.Context, pageID string, hits map[int]*models.Document) []indexedChunk {
+	chunkCount := -1
+	if info, ok := s.store.GetPage(pageID); ok {
+		chunkCount = info.ChunkCount
+	}
+
+	wanted := make(map[int]bool)
+	if s.config.ExpandPageChunks > 0 && chunkCount > 0 && chunkCount <= s.config.ExpandPageChunks {
+		for idx := 0; idx < chunkCount; idx++ {
+			wanted[idx] = true
+		}
+	} else {
+		for idx := range hits {
+			for n := idx - s.config.ExpandNeighbors; n <= idx+s.config.ExpandNeighbors; n++ {
+				if n >= 0 && (chunkCount < 0 || n < chunkCount) {
+					wanted[n] = true
+				}
+			}
+		}
+	}
+
+	chunks := make([]indexedChunk, 0, len(wanted))
+	for idx := range wanted {
+		doc, ok := hits[idx]
+		if !ok {
+			var err error
+			if doc, err = s.store.GetByID(ctx, models.ChunkID(pageID, idx)); err != nil {
+				continue
+			}
+		}
+		chunks = append(chunks, indexedChunk{index: idx, doc: doc})
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].index < chunks[j].index
+	})
+
+	return chunks
+}
+
+// contiguousRuns 순번이 이어지는 청크끼리 묶습니다
+func contiguousRuns(chunks []indexedChunk) [][]*models.Document {
+	var runs [][]*models.Document
+	for i, chunk := range chunks {
+		if i == 0 || chunk.index != chunks[i-1].index+1 {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], chunk.doc)
+	}
+	return runs
+}
+
+// mergeChunks 이어지는 청크들을 하나의 문서로 합칩니다
+// 청크 사이에 겹친 부분(오버랩)은 한 번만 남기고, 제목 경로는 첫 청크의 것만 남깁니다
+// (뒤 청크에서 새로 시작하는 절의 제목은 본문에 그대로 들어있음)
+func mergeChunks(docs []*models.Document) *models.Document {
+	if len(docs) == 1 {
+		return docs[0]
+	}
+
+	merged := *docs[0]
+	path, content := splitHeadingPath(docs[0].Content)
+	for _, doc := range docs[1:] {
+		_, body := splitHeadingPath(doc.Content)
+		if overlap := overlapLength(content, body); overlap > 0 {
+			content += body[overlap:]
+		} else {
+			content += "\n\n" + body
+		}
+	}
+
+	if path != "" {
+		content = path + "\n\n" + content
+	}
+	merged.Content = content
+	merged.Vector = nil
+
+	return &merged
+}
+
+// splitHeadingPath 청크 앞의 제목 경로(예: "[설치 > 빌드]")와 본문을 나눕니다
+func splitHeadingPath(content string) (string, string) {
+	if !strings.HasPrefix(content, "[") {
+		return "", content
+	}
+	end := strings.Index(content, "]\n\n")
+	if end < 0 || strings.Contains(content[:end], "\n") {
+		return "", content
+	}
+	return content[:end+1], content[end+3:]
+}
+
+// overlapLength prev의 끝과 next의 앞이 겹치는 가장 긴 길이를 반환합니다 (minOverlapBytes 미만이면 0)
+func overlapLength(prev, next string) int {
+	start := len(prev) - len(next)
+	if start < 0 {
+		start = 0
+	}
+	for i := start; i <= len(prev)-minOverlapBytes; i++ {
+		if strings.HasPrefix(next, prev[i:]) {
+			return len(prev) - i
+		}
+	}
+	return 0
+}
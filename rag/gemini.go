@@ -0,0 +1,42 @@
+package rag
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// errNoGeminiKey Gemini를 사용하는 기능을 호출했지만 gemini_api_key가 없음
+var errNoGeminiKey = errors.New("gemini_api_key가 설정되지 않았습니다 (답변 생성, HyDE, 추가 검색 질문, gemini 토크나이저에 필요)")
+
+// geminiModels 답변과 질문 생성에 사용하는 Gemini 클라이언트와 모델
+type geminiModels struct {
+	client *genai.Client
+	answer *genai.GenerativeModel
+	query  *genai.GenerativeModel // 추가 검색 질문 생성용 (JSON 응답)
+}
+
+// gemini Gemini 클라이언트를 처음 사용할 때 만들어서 반환합니다
+// 벡터·키워드 검색만 하면 만들지 않으므로 로컬 임베딩 서버만으로도 gemini_api_key 없이 검색할 수 있습니다
+func (s *Searcher) gemini() (*geminiModels, error) {
+	s.geminiOnce.Do(func() {
+		if s.geminiAPIKey == "" {
+			s.geminiErr = errNoGeminiKey
+			return
+		}
+
+		client, err := genai.NewClient(s.ctx, option.WithAPIKey(s.geminiAPIKey))
+		if err != nil {
+			s.geminiErr = fmt.Errorf("Gemini 클라이언트 생성 실패: %w", err)
+			return
+		}
+		s.geminiModels = &geminiModels{
+			client: client,
+			answer: client.GenerativeModel(answerModel),
+			query:  newQueryModel(client),
+		}
+	})
+	return s.geminiModels, s.geminiErr
+}
@@ -0,0 +1,91 @@
+package rag
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"goc-notion-rag/models"
+)
+
+// 검색 방식
+const (
+	ModeVector  = "vector"  // 임베딩 벡터 유사도 검색 (기본값)
+	ModeKeyword = "keyword" // BM25 키워드 검색 (임베딩 API를 호출하지 않음)
+	ModeHybrid  = "hybrid"  // 벡터 검색과 키워드 검색 순위를 RRF로 합침
+)
+
+// rrfK Reciprocal Rank Fusion 상수 (순위가 낮은 결과의 영향을 줄이는 값, 일반적으로 60 사용)
+const rrfK = 60
+
+// hybridCandidateFactor 하이브리드 검색에서 각 검색 방식으로 가져올 후보 수 (TopK의 배수)
+const hybridCandidateFactor = 3
+
+// ParseMode 검색 방식 이름을 검증합니다 (빈 문자열이면 ModeVector)
+func ParseMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ModeVector, nil
+	case ModeVector, ModeKeyword, ModeHybrid:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("지원하지 않는 검색 방식입니다: %s (vector, keyword, hybrid)", mode)
+	}
+}
+
+// fuseRRF 여러 검색 결과 목록을 Reciprocal Rank Fusion으로 합쳐 상위 topK개를 반환합니다
+// 각 목록에서 순위가 r인 결과는 1/(rrfK+r)점을 받고, 여러 목록에 나온 결과는 점수를 더합니다
+// 결과의 Score는 RRF 점수이며 Similarity는 처음 나온 목록의 값을 유지합니다
+func fuseRRF(topK int, lists ...[]models.SearchResult) []models.SearchResult {
+	fused := make(map[string]*models.SearchResult)
+	var order []string
+
+	for _, list := range lists {
+		for rank, result := range list {
+			score := float32(1.0 / float64(rrfK+rank+1))
+			if existing, ok := fused[result.Document.ID]; ok {
+				existing.Score += score
+				if existing.Similarity == 0 {
+					existing.Similarity = result.Similarity
+				}
+				continue
+			}
+			fusedResult := result
+			fusedResult.Score = score
+			fused[result.Document.ID] = &fusedResult
+			order = append(order, result.Document.ID)
+		}
+	}
+
+	results := make([]models.SearchResult, len(order))
+	for i, id := range order {
+		results[i] = *fused[id]
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > topK {
+		results = results[:topK]
+	}
+
+	return results
+}
+
+// cosineSimilarity 두 벡터의 코사인 유사도를 계산합니다 (길이가 다르거나 비어있으면 0)
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
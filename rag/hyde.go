@@ -0,0 +1,103 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"goc-notion-rag/embedding"
+)
+
+// useHyDE HyDE로 벡터 검색하는지 확인합니다 (키워드 검색은 임베딩을 사용하지 않으므로 제외)
+func (s *Searcher) useHyDE() bool {
+	return s.config.HyDE && s.config.Mode != ModeKeyword
+}
+
+// generateHypothetical 질문에 답하는 Notion 문서의 한 부분을 가상으로 작성합니다 (HyDE)
+// 질문과 Notion 본문은 임베딩 공간에서 서로 떨어져 있으므로, 내용이 틀리더라도 문서처럼 쓴 글이 관련 청크와 더 가깝습니다
+// 모델이 작성하지 못하면 빈 문자열을 반환하며, 이때는 질문 임베딩으로 검색합니다
+func (s *Searcher) generateHypothetical(ctx context.Context, question string) (string, Usage, error) {
+	prompt := fmt.Sprintf(`아래 질문에 대한 답이 담긴 Notion 문서의 한 부분을 작성하세요.
+정확한 내용을 모르더라도 실제 문서에 있을 법한 용어와 문장으로, 질문과 같은 언어로 3~5문장을 쓰세요.
+제목, 설명, 출처 표시 없이 본문만 쓰세요.
+
+[Question]
+%s`, question)
+
+	text, usage, err := s.generateAnswer(ctx, prompt)
+	if errors.Is(err, errNoAnswer) {
+		return "", usage, nil
+	}
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	return strings.TrimSpace(text), usage, nil
+}
+
+// queryVector 벡터 검색에 사용할 벡터를 만듭니다
+// hypothetical이 없으면 질문을 RETRIEVAL_QUERY로 임베딩하고, 있으면 저장된 청크와 같은 RETRIEVAL_DOCUMENT로 가상 문서를 임베딩합니다
+// HyDEQueryWeight가 있으면 질문 벡터를 그 비율만큼 섞습니다
+func (s *Searcher) queryVector(ctx context.Context, question, hypothetical string) ([]float32, error) {
+	if hypothetical == "" {
+		vector, err := s.embedder.EmbedText(ctx, question, embedding.TaskRetrievalQuery)
+		if err != nil {
+			return nil, fmt.Errorf("질문 임베딩 실패: %w", err)
+		}
+		return vector, nil
+	}
+
+	documentVector, err := s.embedder.EmbedText(ctx, hypothetical, embedding.TaskRetrievalDocument)
+	if err != nil {
+		return nil, fmt.Errorf("가상 문서 임베딩 실패: %w", err)
+	}
+
+	weight := s.config.HyDEQueryWeight
+	if weight <= 0 {
+		return documentVector, nil
+	}
+
+	questionVector, err := s.embedder.EmbedText(ctx, question, embedding.TaskRetrievalQuery)
+	if err != nil {
+		return nil, fmt.Errorf("질문 임베딩 실패: %w", err)
+	}
+	return blendVectors(documentVector, questionVector, weight), nil
+}
+
+// blendVectors 두 벡터를 각각 정규화한 뒤 (1-weight):weight 비율로 섞고 다시 정규화합니다
+// 길이가 다르면 a를 그대로 반환합니다
+func blendVectors(a, b []float32, weight float32) []float32 {
+	if len(a) != len(b) {
+		return a
+	}
+	if weight > 1 {
+		weight = 1
+	}
+
+	a, b = normalize(a), normalize(b)
+	blended := make([]float32, len(a))
+	for i := range a {
+		blended[i] = (1-weight)*a[i] + weight*b[i]
+	}
+	return normalize(blended)
+}
+
+// normalize 벡터를 길이 1로 정규화한 복사본을 반환합니다 (영벡터는 그대로)
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+
+	scale := float32(1 / math.Sqrt(norm))
+	normalized := make([]float32, len(v))
+	for i, x := range v {
+		normalized[i] = x * scale
+	}
+	return normalized
+}
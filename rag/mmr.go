@@ -0,0 +1,78 @@
+package rag
+
+import (
+	"goc-notion-rag/models"
+)
+
+// mmrCandidateFactor MMR이나 페이지당 제한을 사용할 때 가져올 후보 수 (남길 청크 수의 배수)
+const mmrCandidateFactor = 3
+
+// selectMMR Maximal Marginal Relevance로 관련도가 높으면서 서로 겹치지 않는 청크 n개를 고릅니다
+// 점수는 lambda × 관련도 − (1 − lambda) × (이미 고른 청크와의 최대 코사인 유사도)이며,
+// 관련도는 후보 중 최고 Score를 1로 정규화한 값을 사용합니다 (벡터가 없는 청크는 겹치지 않는 것으로 취급)
+// lambda가 0 이하면 관련도 순서로만 고르고, maxPerPage가 0보다 크면 한 페이지에서 그 수까지만 고릅니다
+// results는 Score 내림차순이어야 하며, 선택된 순서대로 반환합니다
+func selectMMR(results []models.SearchResult, n int, lambda float32, maxPerPage int) []models.SearchResult {
+	if lambda <= 0 || lambda > 1 {
+		lambda = 1
+	}
+
+	var maxScore float32
+	for _, result := range results {
+		maxScore = max(maxScore, result.Score)
+	}
+	relevance := func(result models.SearchResult) float32 {
+		if maxScore <= 0 {
+			return 0
+		}
+		return result.Score / maxScore
+	}
+
+	selected := make([]models.SearchResult, 0, n)
+	used := make([]bool, len(results))
+	perPage := make(map[string]int)
+
+	for len(selected) < n {
+		best := -1
+		var bestScore float32
+		for i, candidate := range results {
+			if used[i] {
+				continue
+			}
+			if maxPerPage > 0 && perPage[pageKey(candidate.Document)] >= maxPerPage {
+				continue
+			}
+
+			// 이미 고른 청크와 가장 비슷한 정도 (관련도만 볼 때는 계산하지 않음)
+			var redundancy float32
+			if lambda < 1 {
+				for _, chosen := range selected {
+					redundancy = max(redundancy, cosineSimilarity(candidate.Document.Vector, chosen.Document.Vector))
+				}
+			}
+
+			score := lambda*relevance(candidate) - (1-lambda)*redundancy
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			// 남은 후보가 없거나 모두 페이지당 제한에 걸림
+			break
+		}
+
+		used[best] = true
+		perPage[pageKey(results[best].Document)]++
+		selected = append(selected, results[best])
+	}
+
+	return selected
+}
+
+// pageKey 청크가 속한 페이지를 구분하는 키를 반환합니다 (원본 페이지 ID가 없으면 청크 ID)
+func pageKey(doc *models.Document) string {
+	if doc.ParentPageID != "" {
+		return doc.ParentPageID
+	}
+	return doc.ID
+}
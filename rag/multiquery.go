@@ -0,0 +1,107 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"goc-notion-rag/models"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+// newQueryModel 추가 검색 질문 생성에 사용할 모델을 만듭니다 (항상 문자열 JSON 배열로 답하도록 응답 스키마 지정)
+func newQueryModel(client *genai.Client) *genai.GenerativeModel {
+	model := client.GenerativeModel(answerModel)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = &genai.Schema{
+		Type:  genai.TypeArray,
+		Items: &genai.Schema{Type: genai.TypeString},
+	}
+	return model
+}
+
+// expandQueries 질문을 다른 표현으로 바꾼 검색 질문, 하위 질문, 한국어/영어 번역을 MultiQuery개까지 만듭니다
+// 짧거나 모호한 질문은 임베딩이 잘 맞지 않으므로 여러 질문으로 함께 검색해서 놓치는 문서를 줄입니다
+// 원래 질문과 같거나 중복된 질문은 빠지며, 에러나 빈 목록이면 호출자가 원래 질문으로만 검색합니다
+func (s *Searcher) expandQueries(ctx context.Context, question string) ([]string, Usage, error) {
+	prompt := fmt.Sprintf(`Notion 문서를 검색하려고 합니다. 아래 질문과 관련된 문서를 더 잘 찾을 수 있도록 검색 질문 %d개를 만드세요.
+- 같은 뜻을 다른 단어로 바꿔 말한 질문
+- 질문이 여러 내용을 묻는다면 각각을 묻는 하위 질문
+- 질문이 한국어면 영어로, 영어면 한국어로 번역한 질문 1개 (반드시 포함)
+각 질문은 그 자체로 이해할 수 있는 한 문장으로 쓰고, 원래 질문을 그대로 반복하지 마세요.
+
+[Question]
+%s`, s.config.MultiQuery, question)
+
+	gemini, err := s.gemini()
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	text, usage, err := s.generate(ctx, gemini.query, prompt)
+	if err != nil {
+		return nil, usage, err
+	}
+
+	var generated []string
+	if err := json.Unmarshal([]byte(text), &generated); err != nil {
+		return nil, usage, fmt.Errorf("검색 질문 응답 파싱 실패: %w", err)
+	}
+
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(question)): true}
+	var queries []string
+	for _, query := range generated {
+		query = strings.TrimSpace(query)
+		key := strings.ToLower(query)
+		if query == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		queries = append(queries, query)
+		if len(queries) == s.config.MultiQuery {
+			break
+		}
+	}
+
+	return queries, usage, nil
+}
+
+// mergeResults 여러 질문의 검색 결과를 청크 ID로 중복을 없애 합치고 점수순으로 상위 limit개를 반환합니다
+// 여러 질문에서 찾은 청크는 가장 높은 점수와 유사도를 사용하며, 점수가 같으면 먼저 나온 순서를 유지합니다
+func mergeResults(limit int, lists ...[]models.SearchResult) []models.SearchResult {
+	merged := make(map[string]*models.SearchResult)
+	var order []string
+
+	for _, list := range lists {
+		for _, result := range list {
+			existing, ok := merged[result.Document.ID]
+			if !ok {
+				mergedResult := result
+				merged[result.Document.ID] = &mergedResult
+				order = append(order, result.Document.ID)
+				continue
+			}
+			if result.Score > existing.Score {
+				existing.Score = result.Score
+			}
+			if result.Similarity > existing.Similarity {
+				existing.Similarity = result.Similarity
+			}
+		}
+	}
+
+	results := make([]models.SearchResult, len(order))
+	for i, id := range order {
+		results[i] = *merged[id]
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results
+}
@@ -0,0 +1,107 @@
+package rag
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultPrompt 기본 제공 프롬프트 프리셋 이름 (Notion 개인 비서, 한국어)
+const DefaultPrompt = "default"
+
+// defaultPromptTemplate 기본 제공 프롬프트 템플릿
+const defaultPromptTemplate = `당신은 나의 Notion 개인 비서입니다. 아래 [Context]를 바탕으로 질문에 답하세요.
+모르는 내용은 지어내지 말고 모른다고 하세요.
+[Context]의 각 문서 앞에는 [1], [2]처럼 출처 번호가 붙어 있습니다.
+답변의 각 문장 끝에 근거가 된 문서의 출처 번호를 [1] 또는 [1, 2]처럼 표시하고, [Context]에 없는 번호는 사용하지 마세요.
+{{if .History}}
+[Conversation]
+{{.History}}
+{{end}}
+[Context]
+{{.Context}}
+
+[Question]
+{{.Question}}
+
+답변:`
+
+// PromptData 프롬프트 템플릿에 전달하는 값
+type PromptData struct {
+	Context  string   // 출처 번호를 붙인 검색 문서 ([1] 제목\n본문, 문서 사이는 ---로 구분)
+	Question string   // 사용자가 입력한 질문
+	History  string   // 이전 대화 (대화 세션이 아니거나 첫 질문이면 빈 문자열)
+	Sources  []Source // 컨텍스트의 출처 목록 (번호순)
+}
+
+// loadPromptTemplates 기본 제공 프롬프트와 설정 파일의 프롬프트 템플릿 파일을 읽어서 이름별로 반환합니다
+// 설정에서 같은 이름(default 포함)을 지정하면 파일의 템플릿을 사용합니다
+func loadPromptTemplates(files map[string]string) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+
+	tmpl, err := template.New(DefaultPrompt).Parse(defaultPromptTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("기본 프롬프트 템플릿 파싱 실패: %w", err)
+	}
+	templates[DefaultPrompt] = tmpl
+
+	for name, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("프롬프트 템플릿 읽기 실패 (%s): %w", name, err)
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("프롬프트 템플릿 파싱 실패 (%s): %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+
+	return templates, nil
+}
+
+// PromptNames 사용할 수 있는 프롬프트 프리셋 이름을 정렬해서 반환합니다
+func (s *Searcher) PromptNames() []string {
+	names := make([]string, 0, len(s.prompts))
+	for name := range s.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkPrompt 프롬프트 프리셋이 있는지 확인합니다
+func (s *Searcher) checkPrompt(name string) error {
+	if _, ok := s.prompts[name]; !ok {
+		return fmt.Errorf("알 수 없는 프롬프트입니다: %s (사용 가능: %s)", name, strings.Join(s.PromptNames(), ", "))
+	}
+	return nil
+}
+
+// buildPrompt 프롬프트 프리셋 템플릿에 컨텍스트, 질문, 이전 대화, 출처를 넣어 프롬프트를 구성합니다
+// name이 비어있으면 설정의 프롬프트를 사용합니다
+func (s *Searcher) buildPrompt(name, contextText string, history []Turn, question string, sources []Source) (string, error) {
+	if name == "" {
+		name = s.config.Prompt
+	}
+	if err := s.checkPrompt(name); err != nil {
+		return "", err
+	}
+
+	data := PromptData{
+		Context:  contextText,
+		Question: question,
+		Sources:  sources,
+	}
+	if len(history) > 0 {
+		data.History = formatHistory(history)
+	}
+
+	var sb strings.Builder
+	if err := s.prompts[name].Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("프롬프트 템플릿 실행 실패 (%s): %w", name, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,43 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"goc-notion-rag/models"
+)
+
+// rerankResults 재순위기로 (질문, 청크) 쌍의 관련도를 다시 계산하여 점수순으로 정렬합니다
+// 결과의 Score는 재순위 점수이며 Similarity는 검색 시의 값을 유지합니다 (상위 청크 선택은 호출자가 함)
+func (s *Searcher) rerankResults(ctx context.Context, question string, results []models.SearchResult) ([]models.SearchResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	// 임베딩할 때와 같이 제목을 본문 앞에 붙여서 채점
+	documents := make([]string, len(results))
+	for i, result := range results {
+		documents[i] = result.Document.Content
+		if result.Document.Title != "" {
+			documents[i] = result.Document.Title + "\n\n" + result.Document.Content
+		}
+	}
+
+	scores, err := s.reranker.Rerank(ctx, question, documents)
+	if err != nil {
+		return nil, fmt.Errorf("재순위 실패 (%s): %w", s.reranker.ModelID(), err)
+	}
+
+	reranked := make([]models.SearchResult, len(results))
+	copy(reranked, results)
+	for i := range reranked {
+		reranked[i].Score = scores[i]
+	}
+	// 점수가 같으면 원래 검색 순위 유지
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+
+	return reranked, nil
+}
@@ -2,180 +2,512 @@ package rag
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"goc-notion-rag/db"
 	"goc-notion-rag/embedding"
+	"goc-notion-rag/internal/retry"
 	"goc-notion-rag/models"
+	"goc-notion-rag/rerank"
 
 	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
 )
 
+// 검색 기본값
+const (
+	DefaultTopK          = 10
+	DefaultMinSimilarity = 0.7
+)
+
+// answerModel 답변 생성에 사용하는 Gemini 모델
+const answerModel = "gemini-2.5-flash"
+
+// noAnswerText 모델이 답변 텍스트를 돌려주지 않았을 때(안전 필터 등) 대신 보여줄 답변
+const noAnswerText = "답변을 생성할 수 없습니다."
+
+// errNoAnswer 모델 응답에 텍스트가 없음 (답변 생성은 noAnswerText로, 질문 재작성 등은 원래 질문으로 대체)
+var errNoAnswer = errors.New("모델 응답에 텍스트가 없습니다")
+
+// Config 검색 설정 (config.json의 "search" 항목)
+type Config struct {
+	TopK          int           `json:"top_k"`          // 가져올 최대 청크 수 (0이면 DefaultTopK)
+	MinSimilarity float32       `json:"min_similarity"` // 최소 유사도 (0이면 DefaultMinSimilarity, 음수면 제한 없음)
+	FallbackK     int           `json:"fallback_k"`     // 최소 유사도를 넘는 청크가 없을 때 대신 사용할 상위 청크 수 (0이면 사용 안 함)
+	Mode          string        `json:"mode"`           // 검색 방식: vector, keyword, hybrid (비어있으면 vector)
+	Rerank        rerank.Config `json:"rerank"`         // 재순위 설정 (provider가 비어있으면 사용 안 함)
+	MMRLambda     float32       `json:"mmr_lambda"`     // MMR 관련도 가중치 (0~1, 1에 가까울수록 관련도 우선, 0이면 MMR 사용 안 함)
+	MaxPerPage    int           `json:"max_per_page"`   // 한 페이지에서 가져올 최대 청크 수 (0이면 제한 없음)
+	HistoryTurns  int           `json:"history_turns"`  // 대화 세션에서 프롬프트에 넣을 이전 대화 수 (0이면 DefaultHistoryTurns, 음수면 대화 기억 안 함)
+	MultiQuery    int           `json:"multi_query"`    // 답변 전에 만들 추가 검색 질문(바꿔 말하기, 하위 질문, 번역) 수 (0이면 사용 안 함)
+
+	HyDE            bool    `json:"hyde"`              // 질문 대신 Gemini로 만든 가상 답변 문서의 임베딩으로 벡터 검색 (keyword 방식에는 적용 안 됨)
+	HyDEQueryWeight float32 `json:"hyde_query_weight"` // HyDE 벡터에 섞을 질문 벡터의 비율 (0~1, 0이면 가상 문서 벡터만 사용)
+
+	ExpandNeighbors  int `json:"expand_neighbors"`   // 검색된 청크의 앞뒤로 함께 넣을 청크 수 (0이면 사용 안 함)
+	ExpandPageChunks int `json:"expand_page_chunks"` // 청크 수가 이 값 이하인 페이지는 페이지 전체를 넣음 (0이면 사용 안 함)
+
+	Prompt  string            `json:"prompt"`  // 답변 프롬프트 프리셋 이름 (비어있으면 DefaultPrompt)
+	Prompts map[string]string `json:"prompts"` // 프롬프트 프리셋 이름별 text/template 파일 경로
+
+	ContextTokens    int    `json:"context_tokens"`    // 프롬프트 컨텍스트에 넣을 문서의 최대 토큰 수 (0이면 DefaultContextTokens, 음수면 제한 없음)
+	ContextTokenizer string `json:"context_tokenizer"` // 컨텍스트 토큰 수 계산 방식: estimate, runes, gemini (비어있으면 estimate)
+}
+
+// WithDefaults 비어있는 값을 기본값으로 채운 설정을 반환합니다
+func (c Config) WithDefaults() Config {
+	if c.TopK <= 0 {
+		c.TopK = DefaultTopK
+	}
+	if c.MinSimilarity == 0 {
+		c.MinSimilarity = DefaultMinSimilarity
+	}
+	if c.Mode == "" {
+		c.Mode = ModeVector
+	}
+	if c.HistoryTurns == 0 {
+		c.HistoryTurns = DefaultHistoryTurns
+	}
+	if c.Prompt == "" {
+		c.Prompt = DefaultPrompt
+	}
+	if c.ContextTokens == 0 {
+		c.ContextTokens = DefaultContextTokens
+	}
+	return c
+}
+
+// Options 벡터 검색 옵션을 반환합니다
+func (c Config) Options(filter db.Filter) db.SearchOptions {
+	c = c.WithDefaults()
+	return db.SearchOptions{TopK: c.TopK, MinSimilarity: c.MinSimilarity, Filter: filter}
+}
+
 // Searcher RAG 검색을 수행하는 구조체
+// 검색 중 공유 상태를 바꾸지 않으므로 여러 고루틴에서 함께 사용할 수 있습니다 (Gemini 클라이언트는 sync.Once로 한 번만 생성)
 type Searcher struct {
-	embedder    *embedding.Embedder
-	store       *db.Store
-	genaiClient *genai.Client
-	model       *genai.GenerativeModel
-	ctx         context.Context
+	embedder  embedding.Embedder
+	reranker  rerank.Reranker // 재순위를 사용하지 않으면 nil
+	store     *db.Store
+	config    Config
+	tokenizer embedding.Tokenizer // 컨텍스트 토큰 수 계산용
+	prompts   map[string]*template.Template
+	modelName string
+	ctx       context.Context
+
+	// Gemini 클라이언트는 처음 사용할 때 생성 (gemini 참고)
+	geminiAPIKey string
+	geminiOnce   sync.Once
+	geminiModels *geminiModels
+	geminiErr    error
 }
 
 // NewSearcher 새로운 RAG 검색기를 생성합니다
-func NewSearcher(ctx context.Context, geminiAPIKey string, store *db.Store) (*Searcher, error) {
-	// 임베딩 생성기 초기화
-	embedder, err := embedding.NewEmbedder(ctx, geminiAPIKey)
-	if err != nil {
-		return nil, fmt.Errorf("임베딩 생성기 초기화 실패: %w", err)
+// embedder는 호출자가 소유하며 Searcher.Close에서 닫지 않습니다
+// Gemini 클라이언트는 답변 생성 등 Gemini를 사용하는 기능을 처음 호출할 때 만들므로, 검색만 하면 geminiAPIKey가 없어도 됩니다
+func NewSearcher(ctx context.Context, geminiAPIKey string, embedder embedding.Embedder, store *db.Store, config Config) (*Searcher, error) {
+	config = config.WithDefaults()
+	if _, err := ParseMode(config.Mode); err != nil {
+		return nil, err
+	}
+
+	s := &Searcher{
+		embedder:     embedder,
+		store:        store,
+		config:       config,
+		modelName:    answerModel,
+		ctx:          ctx,
+		geminiAPIKey: geminiAPIKey,
 	}
 
-	// Gemini 클라이언트 초기화 (생성용)
-	genaiClient, err := genai.NewClient(ctx, option.WithAPIKey(geminiAPIKey))
+	// gemini 토크나이저는 API 요청이 실패하면 근사치로 세므로 API Key가 없으면 미리 알림
+	if config.ContextTokenizer == TokenizerGemini && geminiAPIKey == "" {
+		return nil, errNoGeminiKey
+	}
+	tokenizer, err := newContextTokenizer(ctx, config.ContextTokenizer, s.gemini)
 	if err != nil {
-		embedder.Close()
-		return nil, fmt.Errorf("Gemini 클라이언트 생성 실패: %w", err)
+		return nil, err
+	}
+	s.tokenizer = tokenizer
+
+	// 프롬프트 템플릿 로드 (기본 제공 프롬프트와 설정 파일의 프리셋)
+	if s.prompts, err = loadPromptTemplates(config.Prompts); err != nil {
+		return nil, err
+	}
+	if err := s.checkPrompt(config.Prompt); err != nil {
+		return nil, err
 	}
 
-	model := genaiClient.GenerativeModel("gemini-2.5-flash")
+	// 재순위기 초기화 (설정하지 않았으면 nil)
+	if s.reranker, err = rerank.New(ctx, config.Rerank); err != nil {
+		return nil, fmt.Errorf("재순위기 초기화 실패: %w", err)
+	}
 
-	return &Searcher{
-		embedder:    embedder,
-		store:       store,
-		genaiClient: genaiClient,
-		model:       model,
-		ctx:         ctx,
-	}, nil
+	return s, nil
 }
 
-// Search 질문에 대한 RAG 검색을 수행하고 답변을 반환합니다
-func (s *Searcher) Search(question string) (string, error) {
-	// 1. 질문을 임베딩으로 변환 (검색 시 RETRIEVAL_QUERY 사용)
-	queryVector, err := s.embedder.EmbedText(question, "RETRIEVAL_QUERY")
-	if err != nil {
-		return "", fmt.Errorf("질문 임베딩 실패: %w", err)
+// Search 질문에 대한 RAG 검색을 수행하고 답변, 출처, 검색된 청크(점수 포함), 소요 시간, 토큰 수를 담은 Answer를 반환합니다
+// 최소 유사도를 넘는 청크가 없으면 FallbackK개의 상위 청크로 대신 답변합니다
+// filter가 있으면 조건을 만족하는 청크만 검색합니다 (예: 특정 프로젝트 페이지 하위)
+func (s *Searcher) Search(question string, filter db.Filter) (*Answer, error) {
+	return s.answer(s.ctx, question, "", nil, filter, nil)
+}
+
+// SearchStream Search와 같지만 답변을 생성되는 대로 onToken에 전달합니다
+// ctx를 취소하면 답변 생성을 중단하고 context.Canceled를 감싼 에러를 반환합니다
+// onToken에는 없는 출처 번호를 지운 텍스트가 줄 단위로 전달됩니다 (검증 결과는 Answer.Text와 같음)
+func (s *Searcher) SearchStream(ctx context.Context, question string, filter db.Filter, onToken func(string)) (*Answer, error) {
+	return s.answer(ctx, question, "", nil, filter, onToken)
+}
+
+// answer 검색과 답변 생성을 수행합니다 (onToken이 nil이면 답변 전체를 한 번에 생성)
+// history가 있으면 후속 질문을 독립적인 검색 질문으로 바꿔서 검색하고, 이전 대화를 프롬프트에 포함합니다
+// prompt는 답변 프롬프트 프리셋 이름이며 비어있으면 설정의 프롬프트를 사용합니다
+func (s *Searcher) answer(ctx context.Context, question, prompt string, history []Turn, filter db.Filter, onToken func(string)) (*Answer, error) {
+	start := time.Now()
+	answer := &Answer{Question: question, Query: question, Model: s.modelName}
+
+	// 0. 이전 대화를 참고해서 후속 질문을 독립적인 질문으로 바꿈
+	if len(history) > 0 {
+		query, usage, err := s.rewriteQuestion(ctx, history, question)
+		if err != nil {
+			return nil, fmt.Errorf("질문 재작성 실패: %w", err)
+		}
+		answer.Query = query
+		answer.Usage = answer.Usage.add(usage)
 	}
 
-	// 2. 벡터 DB에서 Top 10 검색 (더 많은 결과를 가져와서 관련 문서를 놓치지 않도록)
-	documents, err := s.store.Search(s.ctx, queryVector, 10)
-	if err != nil {
-		return "", fmt.Errorf("문서 검색 실패: %w", err)
+	// 0-1. 질문에 답하는 가상 문서를 만들어서 그 임베딩으로 검색 (HyDE)
+	if s.useHyDE() {
+		hypothetical, usage, err := s.generateHypothetical(ctx, answer.Query)
+		if err != nil {
+			return nil, fmt.Errorf("가상 문서 생성 실패: %w", err)
+		}
+		answer.Hypothetical = hypothetical
+		answer.Usage = answer.Usage.add(usage)
 	}
 
-	if len(documents) == 0 {
-		return "유사도 0.7 이상인 관련 문서를 찾을 수 없습니다.", nil
+	// 0-2. 검색 질문을 여러 표현으로 바꿔서 함께 검색 (실패하면 원래 질문으로만 검색)
+	if s.config.MultiQuery > 0 {
+		queries, usage, err := s.expandQueries(ctx, answer.Query)
+		answer.Usage = answer.Usage.add(usage)
+		switch {
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != nil:
+			fmt.Printf("⚠️  추가 검색 질문 생성 실패, 원래 질문으로만 검색합니다: %v\n", err)
+		case len(queries) == 0:
+			fmt.Println("⚠️  추가 검색 질문이 없어 원래 질문으로만 검색합니다.")
+		default:
+			answer.Queries = queries
+		}
+	}
+	if len(history) > 0 || s.useHyDE() || s.config.MultiQuery > 0 {
+		answer.Timings.Rewrite = time.Since(start)
+	}
+
+	// 1~2. 설정한 검색 방식으로 관련 청크 검색
+	retrieveStart := time.Now()
+	results, err := s.retrieve(ctx, answer.Query, answer.Hypothetical, answer.Queries, filter)
+	if err != nil {
+		return nil, err
+	}
+	answer.Chunks = results
+	answer.Timings.Retrieve = time.Since(retrieveStart)
+
+	if len(results) == 0 {
+		if s.config.Mode == ModeKeyword {
+			answer.Text = "질문의 단어가 포함된 관련 문서를 찾을 수 없습니다."
+		} else {
+			answer.Text = fmt.Sprintf("유사도 %.2f 이상인 관련 문서를 찾을 수 없습니다.", s.config.MinSimilarity)
+		}
+		answer.Timings.Total = time.Since(start)
+		return answer, nil
 	}
 
-	// 3. 검색된 문서들을 컨텍스트로 구성
-	contextText := s.buildContext(documents)
+	// 3. 검색된 청크를 앞뒤 청크나 페이지 전체로 넓히고, 토큰 예산 안에서 페이지마다 출처 번호를 붙여서 컨텍스트로 구성
+	documents, contextUsage := s.packContext(ctx, s.expandContext(ctx, results))
+	answer.Context = contextUsage
+	sources, numbers := numberSources(documents)
+	contextText := s.buildContext(documents, numbers, sources)
+	answer.Sources = sources
 
 	// 4. 프롬프트 구성
-	prompt := s.buildPrompt(contextText, question)
+	if answer.Prompt, err = s.buildPrompt(prompt, contextText, history, question, sources); err != nil {
+		return nil, err
+	}
 
-	// 5. Gemini에 질문 전송
-	answer, err := s.generateAnswer(prompt)
+	// 5. Gemini에 질문 전송 (스트리밍하면 생성되는 대로 전달)
+	generateStart := time.Now()
+	var (
+		text  string
+		usage Usage
+	)
+	if onToken != nil {
+		// 출처 표시를 줄 단위로 검증한 뒤 전달
+		filter := newCitationFilter(len(sources), onToken)
+		text, usage, err = s.streamAnswer(ctx, answer.Prompt, filter.write)
+		filter.flush()
+	} else {
+		text, usage, err = s.generateAnswer(ctx, answer.Prompt)
+	}
+	if errors.Is(err, errNoAnswer) {
+		text, err = noAnswerText, nil
+	}
 	if err != nil {
-		return "", fmt.Errorf("답변 생성 실패: %w", err)
+		return nil, fmt.Errorf("답변 생성 실패: %w", err)
+	}
+	answer.Usage = answer.Usage.add(usage)
+	answer.Timings.Generate = time.Since(generateStart)
+
+	// 6. 없는 출처 번호를 지우고 인용한 출처 기록
+	text, cited := validateCitations(text, len(sources))
+	answer.Text = text
+	for _, number := range cited {
+		answer.Citations = append(answer.Citations, sources[number-1])
 	}
 
+	answer.Timings.Total = time.Since(start)
 	return answer, nil
 }
 
-// buildContext 검색된 문서들을 컨텍스트 텍스트로 구성합니다
-func (s *Searcher) buildContext(documents []*models.Document) string {
-	var parts []string
+// Retrieve 설정한 검색 방식(vector, keyword, hybrid)으로 질문과 관련된 청크를 검색합니다
+// 하이브리드 검색은 벡터 검색(최소 유사도 적용)과 키워드 검색 결과를 RRF로 합치며,
+// 키워드 검색으로만 찾은 청크에도 질문과의 코사인 유사도를 계산해서 채웁니다
+// 재순위나 MMR을 사용하면 후보를 더 많이 가져와서 재순위·다양화한 뒤 상위 청크만 남깁니다
+// HyDE를 사용하면 가상 문서를 먼저 생성해서 그 임베딩으로 벡터 검색합니다
+func (s *Searcher) Retrieve(question string, filter db.Filter) ([]models.SearchResult, error) {
+	hypothetical := ""
+	if s.useHyDE() {
+		var err error
+		if hypothetical, _, err = s.generateHypothetical(s.ctx, question); err != nil {
+			return nil, fmt.Errorf("가상 문서 생성 실패: %w", err)
+		}
+	}
+	return s.retrieve(s.ctx, question, hypothetical, nil, filter)
+}
 
-	for i, doc := range documents {
-		title := doc.Title
-		if title == "" {
-			title = "제목 없음"
+// retrieve question과 추가 검색 질문 queries로 각각 후보를 검색하고, 청크 ID로 중복을 없애 합친 뒤 재순위·다양화합니다
+// hypothetical이 있으면 question의 벡터 검색에 가상 문서 임베딩을 사용하며, 재순위는 추가 검색 질문이 아닌 question을 기준으로 합니다
+// ctx를 취소하면 단계 사이에서 중단하고 ctx.Err()를 반환합니다
+func (s *Searcher) retrieve(ctx context.Context, question, hypothetical string, queries []string, filter db.Filter) ([]models.SearchResult, error) {
+	results, err := s.retrieveCandidates(ctx, question, hypothetical, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(queries) > 0 {
+		lists := [][]models.SearchResult{results}
+		for _, query := range queries {
+			queryResults, err := s.retrieveCandidates(ctx, query, "", filter)
+			if err != nil {
+				return nil, fmt.Errorf("추가 검색 질문 검색 실패 (%s): %w", query, err)
+			}
+			lists = append(lists, queryResults)
 		}
+		results = mergeResults(s.candidateCount(), lists...)
+	}
 
-		parts = append(parts, fmt.Sprintf("[문서 %d: %s]\n%s", i+1, title, doc.Content))
+	if s.reranker != nil {
+		if results, err = s.rerankResults(ctx, question, results); err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 	}
 
-	return strings.Join(parts, "\n\n---\n\n")
+	// 같은 페이지의 청크만 남지 않도록 다양화하거나 점수순으로 상위 청크 선택
+	n := s.resultCount()
+	if s.config.MMRLambda > 0 || s.config.MaxPerPage > 0 {
+		return selectMMR(results, n, s.config.MMRLambda, s.config.MaxPerPage), nil
+	}
+	if len(results) > n {
+		results = results[:n]
+	}
+	return results, nil
 }
 
-// buildPrompt 컨텍스트와 질문을 포함한 프롬프트를 구성합니다
-func (s *Searcher) buildPrompt(contextText, question string) string {
-	return fmt.Sprintf(`당신은 나의 Notion 개인 비서입니다. 아래 [Context]를 바탕으로 질문에 답하세요.
-모르는 내용은 지어내지 말고 모른다고 하세요.
-
-[Context]
-%s
+// resultCount 최종적으로 남길 청크 수를 반환합니다 (재순위를 사용하면 top_n, 아니면 TopK)
+func (s *Searcher) resultCount() int {
+	if s.reranker != nil && s.config.Rerank.TopN > 0 {
+		return s.config.Rerank.TopN
+	}
+	return s.config.TopK
+}
 
-[Question]
-%s
+// keywordSearch 키워드 검색을 실행하고, 색인에만 있어 건너뛴 청크가 있으면 알립니다
+func (s *Searcher) keywordSearch(ctx context.Context, question string, opts db.SearchOptions) ([]models.SearchResult, error) {
+	results, skipped, err := s.store.KeywordSearch(ctx, question, opts)
+	if err != nil {
+		return nil, fmt.Errorf("키워드 검색 실패: %w", err)
+	}
+	if skipped > 0 {
+		fmt.Printf("⚠️  키워드 색인에만 있는 청크 %d개를 건너뛰었습니다 (색인에서 제거)\n", skipped)
+	}
+	return results, nil
+}
 
-답변:`, contextText, question)
+// candidateCount 검색할 후보 청크 수를 반환합니다
+// 재순위를 사용하면 재순위 후보 수만큼, MMR이나 페이지당 제한을 사용하면 남길 청크 수의 몇 배를 가져옵니다
+func (s *Searcher) candidateCount() int {
+	n := s.resultCount()
+	switch {
+	case s.reranker != nil:
+		return s.config.Rerank.EffectiveCandidates(n)
+	case s.config.MMRLambda > 0 || s.config.MaxPerPage > 0:
+		return n * mmrCandidateFactor
+	default:
+		return n
+	}
 }
 
-// generateAnswer Gemini API를 사용하여 답변을 생성합니다
-// Rate Limit 에러 발생 시 30초 대기 후 재시도합니다
-func (s *Searcher) generateAnswer(prompt string) (string, error) {
-	const maxRetries = 3
-	const retryDelay = 30 * time.Second
+// retrieveCandidates 검색 방식에 맞게 후보 청크를 검색합니다
+// hypothetical이 있으면 벡터 검색에 질문 대신 가상 문서의 임베딩을 사용합니다 (키워드 검색은 항상 질문 사용)
+func (s *Searcher) retrieveCandidates(ctx context.Context, question, hypothetical string, filter db.Filter) ([]models.SearchResult, error) {
+	opts := s.config.Options(filter)
+	opts.TopK = s.candidateCount()
 
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		resp, err := s.model.GenerateContent(s.ctx, genai.Text(prompt))
-		if err == nil {
-			// 성공 시 응답 처리
-			var answerParts []string
-			for _, cand := range resp.Candidates {
-				if cand.Content != nil {
-					for _, part := range cand.Content.Parts {
-						if text, ok := part.(genai.Text); ok {
-							answerParts = append(answerParts, string(text))
-						}
-					}
-				}
-			}
+	if s.config.Mode == ModeKeyword {
+		return s.keywordSearch(ctx, question, opts)
+	}
 
-			if len(answerParts) == 0 {
-				return "답변을 생성할 수 없습니다.", nil
-			}
+	// 질문을 임베딩으로 변환 (검색 시 RETRIEVAL_QUERY 사용, HyDE는 가상 문서 벡터)
+	queryVector, err := s.queryVector(ctx, question, hypothetical)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var results []models.SearchResult
+	if s.config.Mode == ModeHybrid {
+		// 두 방식 모두 TopK보다 많은 후보를 가져와서 합친 순위로 TopK개 선택
+		candidates := opts
+		candidates.TopK = opts.TopK * hybridCandidateFactor
 
-			return strings.Join(answerParts, "\n"), nil
+		vectorResults, err := s.store.Search(ctx, queryVector, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("문서 검색 실패: %w", err)
+		}
+		keywordResults, err := s.keywordSearch(ctx, question, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for i := range keywordResults {
+			keywordResults[i].Similarity = cosineSimilarity(queryVector, keywordResults[i].Document.Vector)
 		}
 
-		lastErr = err
-		errStr := err.Error()
+		results = fuseRRF(opts.TopK, vectorResults, keywordResults)
+	} else {
+		// 벡터 DB에서 Top K 검색
+		results, err = s.store.Search(ctx, queryVector, opts)
+		if err != nil {
+			return nil, fmt.Errorf("문서 검색 실패: %w", err)
+		}
+	}
 
-		// Rate Limit 에러 확인 (429 또는 rate limit 관련 메시지)
-		isRateLimit := strings.Contains(errStr, "429") ||
-			strings.Contains(strings.ToLower(errStr), "rate limit") ||
-			strings.Contains(strings.ToLower(errStr), "quota") ||
-			strings.Contains(strings.ToLower(errStr), "resource exhausted")
+	if len(results) == 0 && s.config.FallbackK > 0 {
+		// 최소 유사도 없이 상위 청크를 다시 검색
+		results, err = s.store.Search(ctx, queryVector, db.SearchOptions{TopK: s.config.FallbackK, Filter: filter})
+		if err != nil {
+			return nil, fmt.Errorf("문서 검색 실패: %w", err)
+		}
+	}
+
+	return results, nil
+}
+
+// Config 검색기에 적용된 검색 설정을 반환합니다 (기본값 적용 후)
+func (s *Searcher) Config() Config {
+	return s.config
+}
+
+// buildContext 검색된 문서들을 출처 번호와 함께 컨텍스트 텍스트로 구성합니다
+// numbers는 documents와 같은 순서의 출처 번호이며, 같은 페이지의 문서는 같은 번호를 사용합니다
+func (s *Searcher) buildContext(documents []*models.Document, numbers []int, sources []Source) string {
+	var parts []string
 
-		if isRateLimit && attempt < maxRetries-1 {
-			fmt.Printf("⚠️  Rate Limit 에러 발생 (시도 %d/%d), %v 후 재시도...\n", attempt+1, maxRetries, retryDelay)
-			time.Sleep(retryDelay)
-			continue
+	for i, doc := range documents {
+		source := sources[numbers[i]-1]
+		parts = append(parts, fmt.Sprintf("[%d] %s\n%s", source.Number, source.Title, doc.Content))
+	}
+
+	return strings.Join(parts, contextSeparator)
+}
+
+// generateAnswer Gemini API를 사용하여 답변을 생성하고 사용한 토큰 수를 함께 반환합니다
+func (s *Searcher) generateAnswer(ctx context.Context, prompt string) (string, Usage, error) {
+	gemini, err := s.gemini()
+	if err != nil {
+		return "", Usage{}, err
+	}
+	return s.generate(ctx, gemini.answer, prompt)
+}
+
+// generate 지정한 모델로 텍스트를 생성하고 사용한 토큰 수를 함께 반환합니다
+// 응답에 텍스트가 없으면 errNoAnswer를 반환하며, Rate Limit 에러 발생 시 30초 대기 후 재시도합니다 (대기 중 ctx 취소 가능)
+func (s *Searcher) generate(ctx context.Context, model *genai.GenerativeModel, prompt string) (string, Usage, error) {
+	var usage Usage
+	text, err := retry.Do(ctx, func() (string, error) {
+		resp, err := model.GenerateContent(ctx, genai.Text(prompt))
+		if err != nil {
+			return "", err
+		}
+
+		var answerParts []string
+		for _, cand := range resp.Candidates {
+			if cand.Content != nil {
+				for _, part := range cand.Content.Parts {
+					if text, ok := part.(genai.Text); ok {
+						answerParts = append(answerParts, string(text))
+					}
+				}
+			}
 		}
 
-		// Rate Limit이 아니거나 최대 재시도 횟수에 도달한 경우
-		return "", err
+		usage = usageOf(resp)
+		if len(answerParts) == 0 {
+			return "", errNoAnswer
+		}
+		return strings.Join(answerParts, "\n"), nil
+	})
+	if err != nil {
+		return "", usage, err
 	}
 
-	return "", fmt.Errorf("최대 재시도 횟수 초과: %w", lastErr)
+	return text, usage, nil
+}
 
+// usageOf 응답의 토큰 사용량을 반환합니다 (모델이 알려주지 않으면 0)
+func usageOf(resp *genai.GenerateContentResponse) Usage {
+	if resp == nil || resp.UsageMetadata == nil {
+		return Usage{}
+	}
+	return Usage{
+		PromptTokens: int(resp.UsageMetadata.PromptTokenCount),
+		OutputTokens: int(resp.UsageMetadata.CandidatesTokenCount),
+		TotalTokens:  int(resp.UsageMetadata.TotalTokenCount),
+	}
 }
 
 // Close 리소스를 정리합니다
 func (s *Searcher) Close() error {
 	var errs []error
 
-	if s.embedder != nil {
-		if err := s.embedder.Close(); err != nil {
+	if s.geminiModels != nil {
+		if err := s.geminiModels.client.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
 
-	if s.genaiClient != nil {
-		if err := s.genaiClient.Close(); err != nil {
+	if s.reranker != nil {
+		if err := s.reranker.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -186,4 +518,3 @@ func (s *Searcher) Close() error {
 
 	return nil
 }
-
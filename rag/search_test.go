@@ -0,0 +1,66 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"goc-notion-rag/db"
+	"goc-notion-rag/models"
+)
+
+// fakeEmbedder 모든 텍스트를 같은 벡터로 임베딩하는 테스트용 임베딩 생성기 (API 요청 없음)
+type fakeEmbedder struct{}
+
+// EmbedText 항상 [1, 0]을 반환합니다
+func (fakeEmbedder) EmbedText(ctx context.Context, text string, taskType string) ([]float32, error) {
+	return []float32{1, 0}, nil
+}
+
+// EmbedTexts 텍스트마다 [1, 0]을 반환합니다
+func (fakeEmbedder) EmbedTexts(ctx context.Context, texts []string, taskType string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{1, 0}
+	}
+	return vectors, nil
+}
+
+func (fakeEmbedder) Dimension() int  { return 2 }
+func (fakeEmbedder) ModelID() string { return "fake/test" }
+func (fakeEmbedder) Close() error    { return nil }
+
+// TestSearcherWithoutGeminiKey gemini_api_key 없이도 검색은 되고, 답변 생성만 errNoGeminiKey로 실패하는지 확인합니다
+func TestSearcherWithoutGeminiKey(t *testing.T) {
+	ctx := context.Background()
+	store, err := db.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	doc := &models.Document{ID: models.ChunkID("page", 0), ParentPageID: "page", Title: "배포", Content: "배포 일정", Vector: []float32{1, 0}}
+	if err := store.AddDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	searcher, err := NewSearcher(ctx, "", fakeEmbedder{}, store, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer searcher.Close()
+
+	results, err := searcher.Retrieve("배포 일정", nil)
+	if err != nil {
+		t.Fatalf("검색 실패: %v", err)
+	}
+	if len(results) != 1 || results[0].Document.ID != doc.ID {
+		t.Errorf("검색 결과 %d개, 기대 1개", len(results))
+	}
+
+	if _, err := searcher.Search("배포 일정", nil); !errors.Is(err, errNoGeminiKey) {
+		t.Errorf("errNoGeminiKey를 기대했지만 %v", err)
+	}
+	if _, err := NewSearcher(ctx, "", fakeEmbedder{}, store, Config{ContextTokenizer: TokenizerGemini}); !errors.Is(err, errNoGeminiKey) {
+		t.Errorf("gemini 토크나이저: errNoGeminiKey를 기대했지만 %v", err)
+	}
+}
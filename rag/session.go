@@ -0,0 +1,153 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"goc-notion-rag/db"
+)
+
+// DefaultHistoryTurns 대화 세션에서 프롬프트에 넣을 이전 대화 수 기본값
+const DefaultHistoryTurns = 3
+
+// historyAnswerRunes 프롬프트에 넣는 이전 답변의 최대 글자 수 (넘으면 앞부분만 사용)
+const historyAnswerRunes = 600
+
+// Turn 대화 세션의 질문 하나와 답변
+type Turn struct {
+	Question string   `json:"question"` // 사용자가 입력한 질문
+	Query    string   `json:"query"`    // 검색에 사용한 질문 (재작성 결과)
+	Answer   string   `json:"answer"`   // 답변 본문
+	Sources  []Source `json:"sources"`  // 답변에서 인용한 출처 (인용이 없으면 프롬프트에 넣은 출처)
+}
+
+// Session 이전 대화를 기억하는 대화 세션
+// 후속 질문(예: "두 번째 건은?")을 이전 대화를 참고해서 독립적인 검색 질문으로 바꾸고, 최근 대화를 프롬프트에 포함합니다
+// Searcher와 달리 대화 기록을 바꾸므로 여러 고루틴에서 함께 사용하면 안 됩니다
+type Session struct {
+	searcher *Searcher
+	turns    []Turn
+	prompt   string // 답변 프롬프트 프리셋 이름 (비어있으면 설정의 프롬프트)
+}
+
+// NewSession 새로운 대화 세션을 생성합니다
+func (s *Searcher) NewSession() *Session {
+	return &Session{searcher: s}
+}
+
+// Ask 이전 대화를 참고해서 질문에 답하고 대화 기록에 추가합니다
+// onToken이 nil이 아니면 SearchStream처럼 답변을 생성되는 대로 전달하며, ctx를 취소하면 기록하지 않고 중단합니다
+func (ss *Session) Ask(ctx context.Context, question string, filter db.Filter, onToken func(string)) (*Answer, error) {
+	answer, err := ss.searcher.answer(ctx, question, ss.prompt, ss.history(), filter, onToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := answer.Citations
+	if len(sources) == 0 {
+		sources = answer.Sources
+	}
+	ss.turns = append(ss.turns, Turn{
+		Question: question,
+		Query:    answer.Query,
+		Answer:   answer.Text,
+		Sources:  sources,
+	})
+
+	return answer, nil
+}
+
+// Turns 지금까지의 대화 기록을 반환합니다
+func (ss *Session) Turns() []Turn {
+	return ss.turns
+}
+
+// Prompt 이 세션에서 사용하는 답변 프롬프트 프리셋 이름을 반환합니다
+func (ss *Session) Prompt() string {
+	if ss.prompt == "" {
+		return ss.searcher.config.Prompt
+	}
+	return ss.prompt
+}
+
+// SetPrompt 이 세션의 이후 답변에 사용할 프롬프트 프리셋을 바꿉니다 (대화 기록은 유지)
+func (ss *Session) SetPrompt(name string) error {
+	if err := ss.searcher.checkPrompt(name); err != nil {
+		return err
+	}
+	ss.prompt = name
+	return nil
+}
+
+// Reset 대화 기록을 지웁니다
+func (ss *Session) Reset() {
+	ss.turns = nil
+}
+
+// history 프롬프트에 넣을 최근 대화를 반환합니다 (대화 기억을 사용하지 않으면 nil)
+func (ss *Session) history() []Turn {
+	limit := ss.searcher.config.HistoryTurns
+	if limit <= 0 || len(ss.turns) == 0 {
+		return nil
+	}
+	if len(ss.turns) > limit {
+		return ss.turns[len(ss.turns)-limit:]
+	}
+	return ss.turns
+}
+
+// rewriteQuestion 이전 대화를 참고해서 후속 질문을 그 자체로 이해할 수 있는 검색 질문으로 바꿉니다
+// 바꿀 필요가 없는 질문은 그대로 돌려받으며, 모델이 답하지 않거나 응답이 비어있으면 원래 질문을 사용합니다
+func (s *Searcher) rewriteQuestion(ctx context.Context, history []Turn, question string) (string, Usage, error) {
+	prompt := fmt.Sprintf(`아래 대화에 이어지는 마지막 질문을, 대화를 보지 않아도 이해할 수 있는 독립적인 검색 질문 한 문장으로 바꾸세요.
+"그것", "두 번째 건"처럼 앞의 대화를 가리키는 표현은 실제 대상(페이지 제목, 고유명사 등)으로 바꾸고,
+이미 독립적인 질문이면 그대로 쓰세요. 설명 없이 바꾼 질문만 답하세요.
+
+[Conversation]
+%s
+
+[Question]
+%s
+
+독립적인 질문:`, formatHistory(history), question)
+
+	rewritten, usage, err := s.generateAnswer(ctx, prompt)
+	if errors.Is(err, errNoAnswer) {
+		return question, usage, nil
+	}
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	rewritten = strings.TrimSpace(rewritten)
+	if rewritten == "" {
+		return question, usage, nil
+	}
+	return rewritten, usage, nil
+}
+
+// formatHistory 이전 대화를 프롬프트에 넣을 텍스트로 구성합니다 (긴 답변은 앞부분만, 인용한 출처 제목 포함)
+func formatHistory(history []Turn) string {
+	var parts []string
+	for _, turn := range history {
+		answer := []rune(turn.Answer)
+		text := string(answer)
+		if len(answer) > historyAnswerRunes {
+			text = string(answer[:historyAnswerRunes]) + "..."
+		}
+
+		var titles []string
+		for _, source := range turn.Sources {
+			titles = append(titles, source.Title)
+		}
+
+		part := fmt.Sprintf("사용자: %s\n비서: %s", turn.Question, text)
+		if len(titles) > 0 {
+			part += "\n(참고한 페이지: " + strings.Join(titles, ", ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"goc-notion-rag/internal/retry"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+)
+
+// streamAnswer Gemini 스트리밍 API로 답변을 생성하면서 받은 텍스트를 onToken에 바로 전달합니다
+// 텍스트를 하나도 받지 못하면 errNoAnswer를 반환하고, 첫 텍스트를 받기 전에 Rate Limit 에러가 나면 30초 대기 후 재시도하며 (최대 3회),
+// 이미 일부를 전달한 뒤의 에러나 ctx 취소는 재시도하지 않고 반환합니다
+func (s *Searcher) streamAnswer(ctx context.Context, prompt string, onToken func(string)) (string, Usage, error) {
+	gemini, err := s.gemini()
+	if err != nil {
+		return "", Usage{}, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < retry.MaxAttempts; attempt++ {
+		var answer strings.Builder
+		var usage Usage
+
+		iter := gemini.answer.GenerateContentStream(ctx, genai.Text(prompt))
+		var err error
+		for {
+			var resp *genai.GenerateContentResponse
+			resp, err = iter.Next()
+			if err != nil {
+				break
+			}
+
+			for _, cand := range resp.Candidates {
+				if cand.Content == nil {
+					continue
+				}
+				for _, part := range cand.Content.Parts {
+					if text, ok := part.(genai.Text); ok && text != "" {
+						answer.WriteString(string(text))
+						onToken(string(text))
+					}
+				}
+			}
+			// 토큰 사용량은 마지막 응답에 누적값으로 들어옴
+			if u := usageOf(resp); u.TotalTokens > 0 {
+				usage = u
+			}
+		}
+
+		if errors.Is(err, iterator.Done) {
+			if answer.Len() == 0 {
+				return "", usage, errNoAnswer
+			}
+			return answer.String(), usage, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			return "", Usage{}, ctx.Err()
+		}
+		if answer.Len() > 0 || !retry.IsRateLimit(err) || attempt == retry.MaxAttempts-1 {
+			// 일부를 이미 출력했거나 Rate Limit이 아니거나 최대 재시도 횟수에 도달한 경우
+			return "", Usage{}, err
+		}
+
+		if err := retry.Wait(ctx, attempt); err != nil {
+			return "", Usage{}, err
+		}
+	}
+
+	return "", Usage{}, fmt.Errorf("최대 재시도 횟수 초과: %w", lastErr)
+}
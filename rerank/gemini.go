@@ -0,0 +1,152 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"goc-notion-rag/internal/retry"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+// defaultGeminiModel Gemini 재순위에 사용하는 기본 모델
+const defaultGeminiModel = "gemini-2.5-flash"
+
+// geminiMaxScore Gemini에게 요청하는 관련도 점수의 최댓값 (0~1 범위로 정규화)
+const geminiMaxScore = 10
+
+// GeminiReranker Gemini에 관련도 채점 프롬프트를 보내서 재순위하는 구현체
+// 모든 문서를 한 번의 요청으로 채점하므로 후보 수만큼 API를 호출하지 않습니다
+type GeminiReranker struct {
+	client    *genai.Client
+	model     *genai.GenerativeModel
+	modelName string
+}
+
+// geminiScore Gemini 채점 응답의 항목 하나
+type geminiScore struct {
+	Index int     `json:"index"`
+	Score float32 `json:"score"`
+}
+
+// NewGeminiReranker 새로운 Gemini 재순위기를 생성합니다
+func NewGeminiReranker(ctx context.Context, cfg Config) (*GeminiReranker, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("gemini 재순위 제공자는 api_key 설정이 필요합니다")
+	}
+
+	client, err := genai.NewClient(ctx, option.WithAPIKey(cfg.APIKey))
+	if err != nil {
+		return nil, fmt.Errorf("Gemini 클라이언트 생성 실패: %w", err)
+	}
+
+	modelName := cfg.Model
+	if modelName == "" {
+		modelName = defaultGeminiModel
+	}
+
+	// 항상 같은 형식의 JSON 배열로 답하도록 응답 스키마 지정
+	model := client.GenerativeModel(modelName)
+	model.SetTemperature(0)
+	model.ResponseMIMEType = "application/json"
+	model.ResponseSchema = &genai.Schema{
+		Type: genai.TypeArray,
+		Items: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"index": {Type: genai.TypeInteger},
+				"score": {Type: genai.TypeNumber},
+			},
+			Required: []string{"index", "score"},
+		},
+	}
+
+	return &GeminiReranker{
+		client:    client,
+		model:     model,
+		modelName: modelName,
+	}, nil
+}
+
+// Rerank 질문에 대한 각 문서의 관련도를 0~1 범위로 반환합니다
+// 응답에서 빠진 문서는 0점으로 처리합니다
+func (r *GeminiReranker) Rerank(ctx context.Context, query string, documents []string) ([]float32, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	prompt := buildScoringPrompt(query, documents)
+	return retry.Do(ctx, func() ([]float32, error) {
+		resp, err := r.model.GenerateContent(ctx, genai.Text(prompt))
+		if err != nil {
+			return nil, err
+		}
+
+		var text strings.Builder
+		for _, cand := range resp.Candidates {
+			if cand.Content == nil {
+				continue
+			}
+			for _, part := range cand.Content.Parts {
+				if t, ok := part.(genai.Text); ok {
+					text.WriteString(string(t))
+				}
+			}
+			break
+		}
+
+		return parseScores(text.String(), len(documents))
+	})
+}
+
+// parseScores Gemini 채점 응답(JSON 배열)을 문서 순서의 0~1 점수로 바꿉니다
+// 점수는 0~geminiMaxScore로 제한하며, 번호가 범위를 벗어난 항목은 무시하고 빠진 문서는 0점으로 처리합니다
+func parseScores(text string, count int) ([]float32, error) {
+	var items []geminiScore
+	if err := json.Unmarshal([]byte(text), &items); err != nil {
+		return nil, fmt.Errorf("채점 응답 파싱 실패: %w", err)
+	}
+
+	scores := make([]float32, count)
+	for _, item := range items {
+		// 프롬프트의 문서 번호는 1부터 시작
+		idx := item.Index - 1
+		if idx < 0 || idx >= count {
+			continue
+		}
+		scores[idx] = min(max(item.Score, 0), geminiMaxScore) / geminiMaxScore
+	}
+
+	return scores, nil
+}
+
+// buildScoringPrompt 문서마다 질문과의 관련도를 채점하도록 요청하는 프롬프트를 구성합니다
+func buildScoringPrompt(query string, documents []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `아래 각 문서가 질문에 답하는 데 얼마나 도움이 되는지 0~%d점으로 채점하세요.
+%d점: 질문에 직접 답하는 내용, 0점: 질문과 관련 없음.
+모든 문서에 대해 {"index": 문서 번호, "score": 점수} 형식의 JSON 배열로만 답하세요.
+
+[Question]
+%s
+`, geminiMaxScore, geminiMaxScore, query)
+
+	for i, doc := range documents {
+		fmt.Fprintf(&sb, "\n[문서 %d]\n%s\n", i+1, doc)
+	}
+
+	return sb.String()
+}
+
+// ModelID 모델 식별자를 반환합니다
+func (r *GeminiReranker) ModelID() string {
+	return ProviderGemini + "/" + r.modelName
+}
+
+// Close 리소스를 정리합니다
+func (r *GeminiReranker) Close() error {
+	return r.client.Close()
+}
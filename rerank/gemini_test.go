@@ -0,0 +1,40 @@
+package rerank
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseScores Gemini 채점 응답을 0~1 점수로 바꾸면서 범위를 벗어난 점수와 번호를 처리하는지 확인합니다
+func TestParseScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []float32
+		wantErr bool
+	}{
+		{name: "정상 응답", text: `[{"index":1,"score":10},{"index":2,"score":5},{"index":3,"score":0}]`, want: []float32{1, 0.5, 0}},
+		{name: "0~10으로 제한", text: `[{"index":1,"score":15},{"index":2,"score":-3},{"index":3,"score":2.5}]`, want: []float32{1, 0, 0.25}},
+		{name: "범위 밖 번호는 무시, 빠진 문서는 0점", text: `[{"index":0,"score":9},{"index":2,"score":8},{"index":4,"score":7}]`, want: []float32{0, 0.8, 0}},
+		{name: "잘못된 JSON", text: `[{"index":1,"score":`, wantErr: true},
+		{name: "배열이 아닌 응답", text: `{"index":1,"score":3}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores, err := parseScores(tt.text, 3)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("에러를 기대했지만 결과를 받았습니다: %v", scores)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(scores, tt.want) {
+				t.Errorf("점수 = %v, 기대 %v", scores, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"goc-notion-rag/internal/httpx"
+	"goc-notion-rag/internal/retry"
+)
+
+// HTTPReranker Cohere/Jina 호환 /rerank 엔드포인트를 사용하는 구현체
+// 같은 API를 제공하는 로컬 서버(llama.cpp, Infinity, vLLM, TEI 호환 프록시 등)의 cross-encoder를 사용할 수 있습니다
+type HTTPReranker struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	modelName  string
+}
+
+// httpRequest /rerank 요청 본문
+type httpRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// httpResponse /rerank 응답 본문
+type httpResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float32 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewHTTPReranker 새로운 HTTP 재순위기를 생성합니다
+func NewHTTPReranker(ctx context.Context, cfg Config) (*HTTPReranker, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("http 재순위 제공자는 base_url 설정이 필요합니다")
+	}
+
+	return &HTTPReranker{
+		httpClient: httpx.NewClient(),
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:     cfg.APIKey,
+		modelName:  cfg.Model,
+	}, nil
+}
+
+// Rerank 질문에 대한 각 문서의 관련도 점수(relevance_score)를 반환합니다
+// 응답에 모든 문서의 index가 정확히 한 번씩 있어야 하며, 빠지거나 겹치면 에러를 반환합니다
+func (r *HTTPReranker) Rerank(ctx context.Context, query string, documents []string) ([]float32, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+
+	return retry.Do(ctx, func() ([]float32, error) {
+		var resp httpResponse
+		req := httpRequest{Model: r.modelName, Query: query, Documents: documents, TopN: len(documents)}
+		if err := httpx.PostJSON(ctx, r.httpClient, r.baseURL+"/rerank", r.apiKey, req, &resp); err != nil {
+			return nil, err
+		}
+
+		if len(resp.Results) != len(documents) {
+			return nil, fmt.Errorf("재순위 응답 개수가 맞지 않습니다 (요청 %d개, 응답 %d개)", len(documents), len(resp.Results))
+		}
+
+		// 응답은 점수순으로 정렬되어 오므로 index 기준으로 입력 순서에 맞춤
+		// 개수가 같고 index가 겹치지 않으면 모든 문서의 점수가 채워짐 (빠진 문서가 0점으로 음수 점수보다 앞서지 않도록)
+		scores := make([]float32, len(documents))
+		seen := make([]bool, len(documents))
+		for _, item := range resp.Results {
+			if item.Index < 0 || item.Index >= len(documents) {
+				return nil, fmt.Errorf("잘못된 재순위 응답 index: %d", item.Index)
+			}
+			if seen[item.Index] {
+				return nil, fmt.Errorf("재순위 응답 index가 중복되었습니다: %d", item.Index)
+			}
+			seen[item.Index] = true
+			scores[item.Index] = item.RelevanceScore
+		}
+
+		return scores, nil
+	})
+}
+
+// ModelID 모델 식별자를 반환합니다
+func (r *HTTPReranker) ModelID() string {
+	return ProviderHTTP + "/" + r.modelName
+}
+
+// Close 리소스를 정리합니다
+func (r *HTTPReranker) Close() error {
+	r.httpClient.CloseIdleConnections()
+	return nil
+}
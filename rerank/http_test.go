@@ -0,0 +1,91 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestHTTPRerankerResponseIndex 응답 index를 입력 순서로 맞추고, 범위를 벗어나거나 겹치거나 빠진 index는 에러로 처리하는지 확인합니다
+func TestHTTPRerankerResponseIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		results string
+		want    []float32
+		wantErr bool
+	}{
+		{
+			name:    "점수순 응답",
+			results: `[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":-1.5},{"index":1,"relevance_score":-3}]`,
+			want:    []float32{-1.5, -3, 0.9},
+		},
+		{name: "index 범위 초과", results: `[{"index":0,"relevance_score":1},{"index":1,"relevance_score":1},{"index":3,"relevance_score":1}]`, wantErr: true},
+		{name: "index 중복", results: `[{"index":0,"relevance_score":1},{"index":1,"relevance_score":1},{"index":1,"relevance_score":2}]`, wantErr: true},
+		{name: "index 누락", results: `[{"index":0,"relevance_score":-1},{"index":2,"relevance_score":-2}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/rerank" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"results":%s}`, tt.results)
+			}))
+			defer server.Close()
+
+			reranker, err := NewHTTPReranker(context.Background(), Config{Provider: ProviderHTTP, BaseURL: server.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reranker.Close()
+
+			scores, err := reranker.Rerank(context.Background(), "질문", []string{"a", "b", "c"})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("에러를 기대했지만 결과를 받았습니다: %v", scores)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(scores, tt.want) {
+				t.Errorf("점수 = %v, 기대 %v", scores, tt.want)
+			}
+		})
+	}
+}
+
+// TestHTTPRerankerCanceled Rate Limit으로 재시도를 기다리는 중 요청 ctx가 취소되면 30초를 기다리지 않고 멈추는지 확인합니다
+func TestHTTPRerankerCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		http.Error(w, "rate limit", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	reranker, err := NewHTTPReranker(context.Background(), Config{Provider: ProviderHTTP, BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reranker.Close()
+
+	start := time.Now()
+	if _, err := reranker.Rerank(ctx, "질문", []string{"a", "b"}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("context.Canceled를 기대했지만 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("취소 후 %v 동안 기다렸습니다", elapsed)
+	}
+}
@@ -0,0 +1,78 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+)
+
+// 재순위 제공자
+const (
+	ProviderNone   = "none"   // 재순위 사용 안 함
+	ProviderGemini = "gemini" // Gemini에 관련도 채점 프롬프트를 보내서 재순위
+	ProviderHTTP   = "http"   // Cohere/Jina 호환 /rerank 엔드포인트 (로컬 reranker 서버 등)
+)
+
+// DefaultCandidates 재순위할 후보 청크 수 기본값
+const DefaultCandidates = 30
+
+// Reranker 질문과 문서 쌍의 관련도를 다시 계산하는 인터페이스
+// 구현체는 여러 고루틴에서 동시에 호출해도 안전해야 합니다
+type Reranker interface {
+	// Rerank 질문에 대한 각 문서의 관련도 점수를 반환합니다 (결과는 입력과 같은 순서, 높을수록 관련)
+	Rerank(ctx context.Context, query string, documents []string) ([]float32, error)
+	// ModelID 제공자와 모델을 나타내는 식별자 (예: "gemini/gemini-2.5-flash")
+	ModelID() string
+	// Close 리소스를 정리합니다
+	Close() error
+}
+
+// Config 재순위 설정 (config.json의 "search.rerank" 항목)
+type Config struct {
+	Provider   string `json:"provider"`   // gemini, http (비어있거나 none이면 사용 안 함)
+	Model      string `json:"model"`      // 모델 이름 (gemini 기본값: gemini-2.5-flash)
+	BaseURL    string `json:"base_url"`   // http 엔드포인트 주소 (예: http://localhost:8080/v1)
+	APIKey     string `json:"api_key"`    // 제공자 API Key (gemini는 gemini_api_key 사용 가능)
+	Candidates int    `json:"candidates"` // 재순위할 후보 청크 수 (0이면 DefaultCandidates)
+	TopN       int    `json:"top_n"`      // 재순위 후 남길 청크 수 (0이면 search.top_k)
+}
+
+// Enabled 재순위를 사용하는지 확인합니다
+func (c Config) Enabled() bool {
+	return c.Provider != "" && c.Provider != ProviderNone
+}
+
+// EffectiveCandidates 재순위할 후보 수를 반환합니다 (남길 청크 수보다 적지 않도록 보정)
+func (c Config) EffectiveCandidates(topN int) int {
+	candidates := c.Candidates
+	if candidates <= 0 {
+		candidates = DefaultCandidates
+	}
+	if candidates < topN {
+		candidates = topN
+	}
+	return candidates
+}
+
+// New 설정에 맞는 재순위기를 생성합니다 (사용하지 않도록 설정했으면 nil)
+func New(ctx context.Context, cfg Config) (Reranker, error) {
+	var (
+		reranker Reranker
+		err      error
+	)
+
+	switch cfg.Provider {
+	case "", ProviderNone:
+		return nil, nil
+	case ProviderGemini:
+		reranker, err = NewGeminiReranker(ctx, cfg)
+	case ProviderHTTP:
+		reranker, err = NewHTTPReranker(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("지원하지 않는 재순위 제공자입니다: %s", cfg.Provider)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return reranker, nil
+}
@@ -2,22 +2,52 @@ package ui
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
+	"goc-notion-rag/db"
+	"goc-notion-rag/models"
 	"goc-notion-rag/rag"
 )
 
+// hypotheticalPreviewRunes 답변 위에 표시할 HyDE 가상 문서의 최대 글자 수
+const hypotheticalPreviewRunes = 120
+
 // Run 간단한 REPL 스타일의 검색 인터페이스를 실행합니다
-func Run(searcher *rag.Searcher) error {
+// filter는 검색 범위의 초기값이며 '/filter' 명령으로 바꿀 수 있습니다
+// 질문은 하나의 대화 세션으로 이어지므로 "두 번째 건은?" 같은 후속 질문도 이전 대화를 참고해서 답합니다
+func Run(searcher *rag.Searcher, filter db.Filter) error {
 	scanner := bufio.NewScanner(os.Stdin)
+	session := searcher.NewSession()
 
 	fmt.Println("📚 Notion RAG 검색")
 	fmt.Println("질문을 입력하세요 (종료: 'exit' 또는 'q', Ctrl+C)")
+	fmt.Println("검색 범위 지정: '/filter title~프로젝트, last_edit>=2025-01-01' (해제: '/filter')")
+	fmt.Println("대화 기록: '/history' (새 대화 시작: '/reset')")
+	fmt.Println("프롬프트 변경: '/prompt <이름>' (목록: '/prompt')")
+	fmt.Println("답변 생성 중 Ctrl+C: 이번 답변만 취소")
+	fmt.Printf("검색 방식: %s, 프롬프트: %s", searcher.Config().Mode, searcher.Config().Prompt)
+	if rerankCfg := searcher.Config().Rerank; rerankCfg.Enabled() {
+		fmt.Printf(" (재순위: %s)", rerankCfg.Provider)
+	}
+	if searcher.Config().HyDE && searcher.Config().Mode != rag.ModeKeyword {
+		fmt.Print(" (HyDE)")
+	}
+	if multiQuery := searcher.Config().MultiQuery; multiQuery > 0 {
+		fmt.Printf(" (추가 검색 질문: %d개)", multiQuery)
+	}
+	fmt.Println()
 	fmt.Println()
 
 	for {
+		if len(filter) > 0 {
+			fmt.Printf("[%s] ", filter)
+		}
 		fmt.Print("> ")
 		if !scanner.Scan() {
 			break
@@ -33,18 +63,67 @@ func Run(searcher *rag.Searcher) error {
 			break
 		}
 
-		// 검색 실행
+		// 검색 범위 변경
+		if question == "/filter" || strings.HasPrefix(question, "/filter ") {
+			parsed, err := db.ParseFilter(strings.TrimPrefix(question, "/filter"))
+			if err != nil {
+				fmt.Printf("❌ 오류: %v\n\n", err)
+				continue
+			}
+			filter = parsed
+			if len(filter) == 0 {
+				fmt.Println("🔓 검색 범위를 해제했습니다.")
+			} else {
+				fmt.Printf("🔎 검색 범위: %s\n", filter)
+			}
+			fmt.Println()
+			continue
+		}
+
+		// 대화 세션 관리
+		if question == "/reset" {
+			session.Reset()
+			fmt.Println("🧹 대화 기록을 지웠습니다. 새 대화를 시작합니다.")
+			fmt.Println()
+			continue
+		}
+		if question == "/history" {
+			printHistory(session.Turns())
+			continue
+		}
+
+		// 답변 프롬프트 프리셋 변경
+		if question == "/prompt" || strings.HasPrefix(question, "/prompt ") {
+			name := strings.TrimSpace(strings.TrimPrefix(question, "/prompt"))
+			if name == "" {
+				fmt.Printf("📝 프롬프트: %s (사용 가능: %s)\n\n", session.Prompt(), strings.Join(searcher.PromptNames(), ", "))
+				continue
+			}
+			if err := session.SetPrompt(name); err != nil {
+				fmt.Printf("❌ 오류: %v\n\n", err)
+				continue
+			}
+			fmt.Printf("📝 프롬프트를 %s(으)로 바꿨습니다.\n\n", name)
+			continue
+		}
+
+		// 검색 실행 (답변은 생성되는 대로 출력)
 		fmt.Println("🔍 검색 중...")
-		answer, err := searcher.Search(question)
+		answer, streamed, err := streamSearch(session, question, filter)
 		if err != nil {
+			if streamed {
+				fmt.Println()
+			}
+			if errors.Is(err, context.Canceled) {
+				fmt.Println("⏹️  답변 생성을 취소했습니다.")
+				fmt.Println()
+				continue
+			}
 			fmt.Printf("❌ 오류: %v\n\n", err)
 			continue
 		}
 
-		// 답변 표시
-		fmt.Println("\n💬 답변:")
-		fmt.Println(answer)
-		fmt.Println()
+		printAnswer(answer, streamed, searcher.Config().MinSimilarity)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -53,3 +132,160 @@ func Run(searcher *rag.Searcher) error {
 
 	return nil
 }
+
+// streamSearch 답변을 생성되는 대로 출력하면서 검색합니다
+// 검색 중 Ctrl+C를 누르면 REPL을 종료하지 않고 이번 답변 생성만 취소합니다
+// 답변을 일부라도 출력했으면 streamed가 true입니다
+func streamSearch(session *rag.Session, question string, filter db.Filter) (*rag.Answer, bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 검색하는 동안만 Ctrl+C를 가로챔 (프롬프트에서는 기존처럼 프로그램 종료)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	streamed := false
+	answer, err := session.Ask(ctx, question, filter, func(token string) {
+		if !streamed {
+			fmt.Println("\n💬 답변:")
+			streamed = true
+		}
+		fmt.Print(token)
+	})
+	return answer, streamed, err
+}
+
+// printAnswer 답변과 출처 목록, 검색된 청크, 소요 시간과 토큰 수를 표시합니다
+// streamed가 true면 답변 본문은 이미 출력했으므로 출처 목록부터 표시합니다
+func printAnswer(answer *rag.Answer, streamed bool, minSimilarity float32) {
+	// 후속 질문을 재작성했으면 실제로 검색한 질문 표시
+	if answer.Query != answer.Question {
+		fmt.Printf("🔁 검색 질문: %s\n", answer.Query)
+	}
+	if answer.Hypothetical != "" {
+		fmt.Printf("📝 가상 문서: %s\n", truncateRunes(answer.Hypothetical, hypotheticalPreviewRunes))
+	}
+	if len(answer.Queries) > 0 {
+		fmt.Printf("🔀 추가 검색 질문: %s\n", strings.Join(answer.Queries, " | "))
+	}
+
+	// 답변 표시
+	if streamed {
+		fmt.Println()
+		if sources := answer.FormatSources(); sources != "" {
+			fmt.Println()
+			fmt.Println(sources)
+		}
+	} else {
+		fmt.Println("\n💬 답변:")
+		fmt.Println(answer.FormatText())
+	}
+	fmt.Println()
+
+	// 참고한 문서와 유사도 표시
+	printSources(answer.Chunks, minSimilarity)
+	printContextUsage(answer.Context)
+
+	fmt.Print("⏱️  ")
+	if answer.Timings.Rewrite > 0 {
+		fmt.Printf("재작성 %s, ", answer.Timings.Rewrite.Round(time.Millisecond))
+	}
+	fmt.Printf("검색 %s, 생성 %s (총 %s)",
+		answer.Timings.Retrieve.Round(time.Millisecond), answer.Timings.Generate.Round(time.Millisecond), answer.Timings.Total.Round(time.Millisecond))
+	if answer.Usage.TotalTokens > 0 {
+		fmt.Printf(" | %s 토큰: 입력 %d, 출력 %d", answer.Model, answer.Usage.PromptTokens, answer.Usage.OutputTokens)
+	}
+	fmt.Println()
+	fmt.Println()
+}
+
+// printSources 답변에 사용한 청크의 제목과 유사도를 표시합니다
+// 키워드·하이브리드 검색은 순위를 정한 점수(BM25, RRF)도 함께 표시하고,
+// 최소 유사도에 못 미치는 청크(대체 검색 결과나 키워드로만 찾은 청크)는 따로 표시합니다
+func printSources(sources []models.SearchResult, minSimilarity float32) {
+	if len(sources) == 0 {
+		return
+	}
+
+	fmt.Println("📎 참고 문서:")
+	for i, source := range sources {
+		title := source.Document.Title
+		if title == "" {
+			title = "제목 없음"
+		}
+
+		var scores []string
+		if source.Similarity != 0 {
+			scores = append(scores, fmt.Sprintf("유사도 %.3f", source.Similarity))
+		}
+		if source.Score != source.Similarity {
+			scores = append(scores, fmt.Sprintf("점수 %.4f", source.Score))
+		}
+
+		mark := ""
+		if source.Similarity != 0 && source.Similarity < minSimilarity {
+			mark = " (유사도 기준 미달)"
+		}
+		fmt.Printf("  %d. %s [%s]%s\n", i+1, title, strings.Join(scores, ", "), mark)
+	}
+	fmt.Println()
+}
+
+// printContextUsage 컨텍스트 토큰 예산 때문에 제외하거나 잘라낸 문서를 표시합니다 (모두 넣었으면 표시하지 않음)
+func printContextUsage(usage rag.ContextUsage) {
+	if !usage.Truncated && len(usage.Dropped) == 0 {
+		return
+	}
+
+	fmt.Printf("✂️  컨텍스트 예산 %d 토큰 중 %d 토큰 사용 (문서 %d개", usage.Budget, usage.Tokens, usage.Documents)
+	if usage.Truncated {
+		fmt.Print(", 마지막 문서 뒷부분 생략")
+	}
+	fmt.Println(")")
+	for _, dropped := range usage.Dropped {
+		title := dropped.Title
+		if title == "" {
+			title = "제목 없음"
+		}
+		fmt.Printf("  - 제외: %s (%d 토큰)\n", title, dropped.Tokens)
+	}
+	fmt.Println()
+}
+
+// printHistory 대화 세션의 질문과 인용한 출처를 순서대로 표시합니다
+func printHistory(turns []rag.Turn) {
+	if len(turns) == 0 {
+		fmt.Println("💭 대화 기록이 없습니다.")
+		fmt.Println()
+		return
+	}
+
+	fmt.Println("💭 대화 기록:")
+	for i, turn := range turns {
+		fmt.Printf("  %d. %s\n", i+1, turn.Question)
+		if turn.Query != turn.Question {
+			fmt.Printf("     🔁 %s\n", turn.Query)
+		}
+		for _, source := range turn.Sources {
+			fmt.Printf("     [%d] %s\n", source.Number, source.Title)
+		}
+	}
+	fmt.Println()
+}
+
+// truncateRunes 텍스트를 한 줄로 합치고 최대 글자 수를 넘으면 뒤를 "..."로 줄입니다
+func truncateRunes(text string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "..."
+}